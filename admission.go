@@ -0,0 +1,70 @@
+package cache
+
+import "time"
+
+// defaultDoorkeeperWindow is how long keys are remembered when DoorkeeperWindow is unset
+const defaultDoorkeeperWindow = time.Minute
+
+// doorkeeper is a bloom filter of the keys seen within the current window
+type doorkeeper struct {
+	bits  []uint64
+	hash  func(key string) uint64
+	reset time.Time
+}
+
+// newDoorkeeper creates a doorkeeper sized for the given number of keys
+func newDoorkeeper(keys int, hash func(key string) uint64) *doorkeeper {
+	words := max(keys*10/64+1, 16) // About 10 bits per key for a ~1% false positive rate
+	return &doorkeeper{bits: make([]uint64, words), hash: hash, reset: time.Now()}
+}
+
+// see records a key and reports whether it was already seen in the window
+func (d *doorkeeper) see(key string) bool {
+	h := d.hash(key)
+	h1, h2 := h, h>>32|h<<32
+	m := uint64(len(d.bits) * 64)
+	seen := true
+	for i := uint64(0); i < 4; i++ {
+		bit := (h1 + i*h2) % m
+		if d.bits[bit/64]&(1<<(bit%64)) == 0 {
+			seen = false
+			d.bits[bit/64] |= 1 << (bit % 64)
+		}
+	}
+	return seen
+}
+
+// roll clears the filter once the window has passed
+func (d *doorkeeper) roll(window time.Duration) {
+	if time.Since(d.reset) >= window {
+		clear(d.bits)
+		d.reset = time.Now()
+	}
+}
+
+// sighted records that a key was requested or written and reports whether
+// it had already been seen within the doorkeeper window
+func (c *Cache) sighted(strKey string) bool {
+	if c.doorkeeper == nil {
+		return true
+	}
+	window := c.CacheOpts.DoorkeeperWindow
+	if window <= 0 {
+		window = defaultDoorkeeperWindow
+	}
+	c.doorkeeper.roll(window)
+	return c.doorkeeper.see(strKey)
+}
+
+// admit decides whether a Put may insert a key. Keys already in the cache or
+// seen before within the window are always admitted; first-seen keys are
+// rejected with probability DoorkeeperRejectRate.
+func (c *Cache) admit(strKey string) bool {
+	if c.doorkeeper == nil {
+		return true
+	}
+	if _, found := c.items.Get(strKey); found {
+		return true
+	}
+	return c.sighted(strKey) || c.float64() >= c.CacheOpts.DoorkeeperRejectRate
+}
@@ -0,0 +1,61 @@
+package cache
+
+import "time"
+
+// Alarm describes a window in which the cache degraded
+type Alarm struct {
+	Window       time.Duration
+	Hits         int
+	Misses       int
+	Evictions    int
+	HitRatio     float64 // Hits over Get requests, 1 if there were none
+	EvictionRate float64 // Evictions per second
+
+	LowHitRatio      bool // HitRatio was below AlarmMinHitRatio
+	HighEvictionRate bool // EvictionRate was above AlarmMaxEvictionRate
+}
+
+// alarmLoop checks the hit ratio and eviction rate of every alarm window
+// and calls OnAlarm when either crosses its threshold
+func (c *Cache) alarmLoop(done <-chan struct{}) {
+	window := c.CacheOpts.AlarmWindow
+	if window <= 0 {
+		window = time.Minute
+	}
+	ticker := time.NewTicker(window)
+	defer ticker.Stop()
+
+	var last Metrics // Counters start from zero
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			m := c.Metrics()
+			if alarm, raised := c.checkAlarm(last, m, window); raised {
+				c.callback("OnAlarm", func() { c.CacheOpts.OnAlarm(alarm) })
+			}
+			last = m
+		}
+	}
+}
+
+// checkAlarm compares the counters at both ends of a window with the alarm
+// thresholds
+func (c *Cache) checkAlarm(from, to Metrics, window time.Duration) (Alarm, bool) {
+	a := Alarm{
+		Window:    window,
+		Hits:      to.Hits - from.Hits,
+		Misses:    to.Misses - from.Misses,
+		Evictions: to.Evictions - from.Evictions,
+		HitRatio:  1,
+	}
+	if requests := a.Hits + a.Misses; requests > 0 {
+		a.HitRatio = float64(a.Hits) / float64(requests)
+	}
+	a.EvictionRate = float64(a.Evictions) / window.Seconds()
+
+	a.LowHitRatio = a.HitRatio < c.CacheOpts.AlarmMinHitRatio
+	a.HighEvictionRate = c.CacheOpts.AlarmMaxEvictionRate > 0 && a.EvictionRate > c.CacheOpts.AlarmMaxEvictionRate
+	return a, a.LowHitRatio || a.HighEvictionRate
+}
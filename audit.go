@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// AuditOp is an audited cache operation
+type AuditOp string
+
+const (
+	AuditGet    AuditOp = "get"
+	AuditPut    AuditOp = "put"
+	AuditDelete AuditOp = "delete"
+)
+
+// AuditRecord describes one audited operation
+type AuditRecord struct {
+	Op        AuditOp
+	Key       string
+	Size      int    // Bytes read or written
+	Outcome   string // "hit", "stale", "miss", "stored", "deleted", "absent" or "error"
+	Err       error
+	Latency   time.Duration
+	RequestID string // Set on the operation's context with WithRequestID
+}
+
+// AuditSink receives a record of every read, write and removal of an item,
+// e.g. for compliance logging of access to personal data. Reads include
+// GetReader, GetRange, GetStale, Has and Snapshot, which records a read of
+// each item it holds, and removals include DeleteFunc, which records each
+// item it removes. It is called after the operation with the cache unlocked.
+type AuditSink interface {
+	Audit(AuditRecord)
+}
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying a request ID for the audit log
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// audit sends the record of an operation to the AuditSink
+func (c *Cache) audit(ctx context.Context, op AuditOp, key []byte, size int, start time.Time, outcome string, err error) {
+	if err != nil {
+		outcome = "error"
+		if op == AuditGet && isMiss(err) {
+			outcome = "miss"
+		}
+	}
+	r := AuditRecord{
+		Op:      op,
+		Key:     c.displayKey(key),
+		Size:    size,
+		Outcome: outcome,
+		Err:     err,
+		Latency: time.Since(start),
+	}
+	r.RequestID, _ = ctx.Value(requestIDKey{}).(string)
+	c.callback("Audit", func() { c.CacheOpts.Audit.Audit(r) })
+}
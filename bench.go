@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"context"
+	"math/rand"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// benchSamples is the number of latencies each Bench worker keeps for the
+// percentiles
+const benchSamples = 10000
+
+// BenchOpts describes a synthetic workload run by Bench
+type BenchOpts struct {
+	Duration  time.Duration // Defaults to 10 seconds
+	Workers   int           // Concurrent clients, defaults to GOMAXPROCS
+	Keys      int           // Size of the key space, defaults to 100000
+	ZipfS     float64       // Skew of a zipfian key distribution, must be > 1; 0 picks keys uniformly
+	ReadRatio float64       // Fraction of operations that are Gets; misses are filled with a Put
+	ValueSize int           // Defaults to 100 bytes
+	TTL       time.Duration // Passed to every Put
+	Seed      int64         // Seeds the workers' random sources
+}
+
+// BenchResult summarizes a Bench run
+type BenchResult struct {
+	Elapsed    time.Duration
+	Ops        int     // Gets and Puts, including fills of misses
+	Errors     int     // Puts that failed
+	Throughput float64 // Operations per second
+	HitRatio   float64 // Hits over Gets, 1 if there were none
+
+	P50, P90, P99, Max time.Duration // Latencies of single operations
+}
+
+// Bench runs a workload against c until opts.Duration elapses or ctx is
+// done, to size a cache before production. c can be a local cache, see
+// AsCacher, or a client of a remote one.
+func Bench(ctx context.Context, c Cacher, opts BenchOpts) BenchResult {
+	if opts.Duration <= 0 {
+		opts.Duration = 10 * time.Second
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+	if opts.Keys <= 0 {
+		opts.Keys = 100000
+	}
+	if opts.ValueSize <= 0 {
+		opts.ValueSize = 100
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var r BenchResult
+	var gets, hits int
+	var latencies []time.Duration
+	start := time.Now()
+	for w := 0; w < opts.Workers; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			b := newBenchWorker(opts, seed)
+			b.run(ctx, c)
+
+			mu.Lock()
+			defer mu.Unlock()
+			r.Ops += b.ops
+			r.Errors += b.errors
+			gets += b.gets
+			hits += b.hits
+			r.Max = max(r.Max, b.max)
+			latencies = append(latencies, b.samples...)
+		}(opts.Seed + int64(w))
+	}
+	wg.Wait()
+
+	r.Elapsed = time.Since(start)
+	r.Throughput = float64(r.Ops) / r.Elapsed.Seconds()
+	r.HitRatio = 1
+	if gets > 0 {
+		r.HitRatio = float64(hits) / float64(gets)
+	}
+	slices.Sort(latencies)
+	r.P50, r.P90, r.P99 = percentile(latencies, 0.5), percentile(latencies, 0.9), percentile(latencies, 0.99)
+	return r
+}
+
+// benchWorker is a single Bench client
+type benchWorker struct {
+	opts  BenchOpts
+	rng   *rand.Rand
+	zipf  *rand.Zipf
+	value []byte
+
+	ops, errors, gets, hits int
+	max                     time.Duration
+	samples                 []time.Duration // Reservoir sample of latencies
+}
+
+// newBenchWorker creates a Bench client with its own random source
+func newBenchWorker(opts BenchOpts, seed int64) *benchWorker {
+	b := &benchWorker{opts: opts, rng: rand.New(rand.NewSource(seed)), value: make([]byte, opts.ValueSize)}
+	if opts.ZipfS > 1 {
+		b.zipf = rand.NewZipf(b.rng, opts.ZipfS, 1, uint64(opts.Keys-1))
+	}
+	return b
+}
+
+// run issues operations until ctx is done
+func (b *benchWorker) run(ctx context.Context, c Cacher) {
+	for ctx.Err() == nil {
+		key := []byte("bench:" + strconv.Itoa(b.key()))
+		if b.rng.Float64() < b.opts.ReadRatio {
+			b.gets++
+			start := time.Now()
+			_, err := c.Get(key)
+			b.observe(time.Since(start))
+			if err == nil {
+				b.hits++
+				continue
+			}
+		}
+		start := time.Now()
+		if err := c.Put(key, b.value, b.opts.TTL); err != nil {
+			b.errors++
+		}
+		b.observe(time.Since(start))
+	}
+}
+
+// key picks the next key from the key space
+func (b *benchWorker) key() int {
+	if b.zipf != nil {
+		return int(b.zipf.Uint64())
+	}
+	return b.rng.Intn(b.opts.Keys)
+}
+
+// observe records the latency of an operation
+func (b *benchWorker) observe(d time.Duration) {
+	b.ops++
+	b.max = max(b.max, d)
+	if len(b.samples) < benchSamples {
+		b.samples = append(b.samples, d)
+	} else if i := b.rng.Intn(b.ops); i < benchSamples {
+		b.samples[i] = d
+	}
+}
+
+// percentile returns the p-th percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[min(int(p*float64(len(sorted))), len(sorted)-1)]
+}
@@ -0,0 +1,88 @@
+package cache
+
+import "time"
+
+// Entry is an item to load with BulkLoad
+type Entry struct {
+	Key   []byte
+	Value []byte
+	TTL   time.Duration // 0 uses the cache TTL
+}
+
+// BulkLoad inserts many items at once, e.g. to warm up a cache. It takes the
+// lock once, sizes an empty cache's maps for the entries up front and defers
+// eviction until every entry is inserted, so entries are recorded in one pass
+// with later entries the most recently used. Admission control and load
+// shedding do not apply. On an error, the entries before the failing one stay
+// loaded.
+func (c *Cache) BulkLoad(entries []Entry) error {
+	defer c.reclaimGroup() // Runs after unlocking
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	if c.frozen {
+		return c.frozenError()
+	}
+	c.presize(len(entries))
+	defer c.makeRoom("", 0, 0)
+
+	now := c.now()
+	for _, e := range entries {
+		strKey := c.storageKey(e.Key)
+		if err := c.checkReservation(strKey, 0); err != nil {
+			return err
+		}
+		if err := c.checkCollision(strKey, e.Key); err != nil {
+			return err
+		}
+		value, err := c.encode(strKey, e.Value)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			value = []byte{}
+		}
+		if c.CacheOpts.ChunkSize > 0 && len(value) > c.CacheOpts.ChunkSize {
+			if err := c.putChunked(strKey, value); err != nil {
+				return err
+			}
+		} else {
+			if !c.fits(1, int64(len(value))) {
+				return ErrValueTooLarge
+			}
+			if err := c.enforceQuota(strKey, len(value)); err != nil {
+				return err
+			}
+			if _, found := c.items.Get(strKey); found {
+				c.dropChunks(strKey)
+				c.drop(strKey)
+			}
+			c.insert(strKey, value, now)
+		}
+		if e.TTL > 0 {
+			c.setTTL(strKey, e.TTL)
+		}
+		c.setFingerprint(strKey, e.Key)
+	}
+	return nil
+}
+
+// presize replaces the maps of an empty cache with ones sized for n entries
+func (c *Cache) presize(n int) {
+	if c.items.Len() > 0 || n <= 0 {
+		return
+	}
+	if _, ok := c.items.(mapStore); ok {
+		c.items = make(mapStore, n)
+	}
+	if p, ok := c.policy.(*lruPolicy); ok {
+		p.nodes = make(map[string]*lruNode, n)
+	}
+	c.timestamps = make(map[string]time.Time, n)
+	if c.CacheOpts.HashKeys {
+		c.fingerprints = make(map[string]uint64, n)
+	}
+}
@@ -0,0 +1,29 @@
+package cache
+
+import "runtime/debug"
+
+// DefaultErrorHandler receives the internal non-fatal errors of caches that
+// have no ErrorHandler of their own. Errors are dropped if it is nil.
+var DefaultErrorHandler func(error)
+
+// callback runs a user callback, recovering from a panic so it cannot take
+// down the process or abandon an eviction half way. The panic is reported to
+// the ErrorHandler as a *PanicError.
+func (c *Cache) callback(name string, fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.reportError(&PanicError{Callback: name, Value: r, Stack: debug.Stack()})
+		}
+	}()
+	fn()
+}
+
+// reportError passes a non-fatal error to the cache's ErrorHandler, or to
+// DefaultErrorHandler if it has none
+func (c *Cache) reportError(err error) {
+	if c.CacheOpts.ErrorHandler != nil {
+		c.CacheOpts.ErrorHandler(err)
+	} else if DefaultErrorHandler != nil {
+		DefaultErrorHandler(err)
+	}
+}
@@ -0,0 +1,110 @@
+package cache
+
+import "time"
+
+// capacityHold is the room reserved for a namespace with ReserveCapacity
+type capacityHold struct {
+	bytes   int64
+	expires time.Time
+}
+
+// ReserveCapacity guarantees a namespace room for size bytes of values for
+// d, e.g. for the working set of a batch job. Cold entries of other
+// namespaces are evicted right away to make the room, and while the
+// reservation lasts other namespaces' writes leave it free and evictions
+// spare the namespace's entries as long as they fit in it. Reserving again
+// replaces the namespace's reservation. Reservations need MaxBytes and fail
+// with ErrOverReserved if together they would exceed it.
+func (c *Cache) ReserveCapacity(namespace string, size int64, d time.Duration) error {
+	defer c.reclaimGroup() // Runs after unlocking
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	total := size
+	for ns, hold := range c.activeHolds() {
+		if ns != namespace {
+			total += hold.bytes
+		}
+	}
+	if c.CacheOpts.MaxBytes <= 0 || total > c.CacheOpts.MaxBytes {
+		return ErrOverReserved
+	}
+	if c.holds == nil {
+		c.holds = make(map[string]capacityHold)
+	}
+	c.holds[namespace] = capacityHold{bytes: size, expires: c.now().Add(d)}
+	for c.full("", 0, 0) {
+		victim := c.unreservedVictim()
+		if victim == "" {
+			break
+		}
+		c.trace(victim, "evict", "reservation")
+		c.remove(victim)
+		c.evictions++
+	}
+	return nil
+}
+
+// ReleaseCapacity ends a namespace's reservation before it expires
+func (c *Cache) ReleaseCapacity(namespace string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+	if _, ok := c.holds[namespace]; ok {
+		delete(c.holds, namespace)
+		c.freeRoom() // Puts waiting for room may fit now
+	}
+}
+
+// activeHolds returns the unexpired reservations, dropping expired ones
+func (c *Cache) activeHolds() map[string]capacityHold {
+	now := c.now()
+	for ns, hold := range c.holds {
+		if now.After(hold.expires) {
+			delete(c.holds, ns)
+		}
+	}
+	return c.holds
+}
+
+// heldBytes returns the reserved bytes not used yet by their namespaces,
+// less what a write of size bytes to namespace ns takes of its own reservation
+func (c *Cache) heldBytes(ns string, size int64) int64 {
+	var held int64
+	for hns, hold := range c.activeHolds() {
+		unused := max(hold.bytes-c.nsBytes[hns], 0)
+		if hns == ns {
+			unused = max(unused-size, 0)
+		}
+		held += unused
+	}
+	return held
+}
+
+// reserved reports whether a key is spared by evictions, being within its
+// namespace's reservation
+func (c *Cache) reserved(key string) bool {
+	ns := c.namespaceOf(key)
+	hold, ok := c.holds[ns]
+	return ok && !c.now().After(hold.expires) && c.nsBytes[ns] <= hold.bytes
+}
+
+// unreservedVictim returns the next key to evict that no reservation
+// spares, "" if there is none
+func (c *Cache) unreservedVictim() string {
+	victim := ""
+	c.policy.Iterate(func(key string) bool {
+		if !c.reserved(key) {
+			victim = key
+			return false
+		}
+		return true
+	})
+	return victim
+}
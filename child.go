@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// WritePropagation decides whether writes to a child cache reach its parent
+type WritePropagation int
+
+const (
+	// WriteLocal stores writes in the child cache only
+	WriteLocal WritePropagation = iota
+	// WriteThrough stores writes in the child cache and its parent
+	WriteThrough
+)
+
+// ChildOpts contains the options of a child cache
+type ChildOpts struct {
+	CacheOpts
+	Writes WritePropagation
+}
+
+// ChildCache is a cache whose misses fall through to a parent cache, e.g. a
+// request-scoped cache in front of a process-wide one. Values found in the
+// parent are copied into the child. A ChildCache is itself a Cacher, so
+// children can be chained. Only its own methods fall back to the parent;
+// Local gives access to the rest of the child's own cache.
+type ChildCache struct {
+	local  *Cache
+	parent Cacher
+	writes WritePropagation
+}
+
+// NewChildCache creates a cache falling back to parent on misses
+func NewChildCache(parent Cacher, opts ChildOpts) *ChildCache {
+	return &ChildCache{local: NewCache(opts.CacheOpts), parent: parent, writes: opts.Writes}
+}
+
+// Local returns the child's own cache, without the fallback to the parent
+func (c *ChildCache) Local() *Cache {
+	return c.local
+}
+
+// Get retrieves an item from the child cache, or from the parent on a miss
+func (c *ChildCache) Get(key []byte) ([]byte, error) {
+	return c.GetContext(context.Background(), key)
+}
+
+// GetContext is Get with a context carrying the request ID for the audit log
+func (c *ChildCache) GetContext(ctx context.Context, key []byte) ([]byte, error) {
+	value, err := c.local.GetContext(ctx, key)
+	if err == nil || !isMiss(err) {
+		return value, err
+	}
+	value, err = c.parent.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.local.PutContext(ctx, key, value); err != nil {
+		c.local.reportError(err)
+	}
+	return value, nil
+}
+
+// Lookup is Get reporting whether the item was found instead of an error
+func (c *ChildCache) Lookup(key []byte) (value []byte, ok bool) {
+	value, err := c.Get(key)
+	return value, err == nil
+}
+
+// GetOrLoad retrieves an item from the child cache or its parent, calling
+// load only when both miss. Loads are deduplicated like Cache.GetOrLoad, and
+// the loaded value is stored like Put.
+func (c *ChildCache) GetOrLoad(ctx context.Context, key []byte, load func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	return c.local.GetOrLoad(ctx, key, func(ctx context.Context) ([]byte, error) {
+		value, err := c.parent.Get(key)
+		if err == nil || !isMiss(err) {
+			return value, err
+		}
+		if value, err = load(ctx); err != nil {
+			return nil, err
+		}
+		if c.writes == WriteThrough {
+			if err := c.parent.Put(key, value, 0); err != nil {
+				c.local.reportError(err)
+			}
+		}
+		return value, nil
+	})
+}
+
+// Has checks if a key exists in the child cache or its parent
+func (c *ChildCache) Has(key []byte) bool {
+	return c.local.Has(key) || c.parent.Has(key)
+}
+
+// Delete removes an item from the child cache, and from the parent when
+// writing through, reporting whether the child held it
+func (c *ChildCache) Delete(key []byte) bool {
+	deleted := c.local.Delete(key)
+	if p, ok := c.parent.(interface{ Delete(key []byte) bool }); ok && c.writes == WriteThrough {
+		p.Delete(key)
+	}
+	return deleted
+}
+
+// Close closes the child's own cache, leaving the parent open
+func (c *ChildCache) Close(ctx context.Context) error {
+	return c.local.Close(ctx)
+}
+
+// Put stores an item in the child cache with the given TTL, 0 for the
+// child's TTL, and in the parent when writing through
+func (c *ChildCache) Put(key, value []byte, ttl time.Duration) error {
+	if err := c.local.PutWithTTL(key, value, ttl); err != nil {
+		return err
+	}
+	if c.writes == WriteThrough {
+		return c.parent.Put(key, value, ttl)
+	}
+	return nil
+}
+
+// cacher adapts a Cache to the Cacher interface
+type cacher struct {
+	*Cache
+}
+
+func (c cacher) Put(key, value []byte, ttl time.Duration) error {
+	return c.Cache.PutWithTTL(key, value, ttl)
+}
+
+// AsCacher returns the cache as a Cacher, e.g. to be the parent of a child cache
+func (c *Cache) AsCacher() Cacher {
+	return cacher{c}
+}
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"encoding/binary"
+	"strconv"
+)
+
+const (
+	chunkPrefix  = "\x00chunk\x00" // Prefix of the internal keys of chunks
+	manifestSize = 12              // Size of a manifest entry value
+)
+
+// chunkKey returns the internal key of the i-th chunk of a value
+func chunkKey(key string, i int) string {
+	return chunkPrefix + key + "\x00" + strconv.Itoa(i)
+}
+
+// putChunked splits a large value into ChunkSize entries plus a manifest entry
+// stored under the key itself
+func (c *Cache) putChunked(key string, value []byte) error {
+	size := c.CacheOpts.ChunkSize
+	parts := make([][]byte, 0, (len(value)+size-1)/size)
+	for start := 0; start < len(value); start += size {
+		end := start + size
+		if end > len(value) {
+			end = len(value)
+		}
+		parts = append(parts, value[start:end])
+	}
+	return c.putChunks(key, parts)
+}
+
+// putChunks stores the parts of a value as chunk entries plus a manifest
+// entry. The chunks share the manifest's timestamp and recency, and evicting
+// any of them evicts the whole value.
+func (c *Cache) putChunks(key string, parts [][]byte) error {
+	n := len(parts)
+	total := manifestSize
+	for _, part := range parts {
+		total += len(part)
+	}
+	if !c.fits(n+1, int64(total)) {
+		return ErrValueTooLarge
+	}
+	if err := c.enforceQuota(key, total); err != nil {
+		return err
+	}
+
+	if _, found := c.items.Get(key); found {
+		c.dropChunks(key)
+		c.drop(key)
+	}
+
+	// Make room for every chunk plus the manifest
+	c.makeRoom(c.namespaceOf(key), n+1, int64(total))
+
+	now := c.now()
+	chunkKeys := make([]string, n)
+	for i, part := range parts {
+		chunkKeys[i] = chunkKey(key, i)
+		c.chunkOwner[chunkKeys[i]] = key
+		c.setItem(chunkKeys[i], part)
+		c.timestamps[chunkKeys[i]] = now
+		c.policy.RecordInsert(chunkKeys[i])
+	}
+
+	manifest := make([]byte, manifestSize)
+	binary.BigEndian.PutUint64(manifest, uint64(total-manifestSize))
+	binary.BigEndian.PutUint32(manifest[8:], uint32(n))
+	c.setItem(key, manifest)
+	c.timestamps[key] = now
+	c.stamp(key, now)
+	c.chunks[key] = chunkKeys
+	c.policy.RecordInsert(key)
+	c.reschedule(key)
+	return nil
+}
+
+// joinChunks reassembles a chunked value
+func (c *Cache) joinChunks(chunkKeys []string) []byte {
+	size := 0
+	for _, chunkKey := range chunkKeys {
+		size += len(c.stored(chunkKey))
+	}
+	value := make([]byte, 0, size)
+	for _, chunkKey := range chunkKeys {
+		value = append(value, c.stored(chunkKey)...)
+	}
+	return value
+}
+
+// dropChunks deletes the chunks of a value, leaving its manifest entry in place
+func (c *Cache) dropChunks(key string) {
+	chunkKeys, ok := c.chunks[key]
+	if !ok {
+		return
+	}
+	for _, chunkKey := range chunkKeys {
+		c.drop(chunkKey)
+		delete(c.chunkOwner, chunkKey)
+	}
+	delete(c.chunks, key)
+}
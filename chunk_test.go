@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestChunkedValues(t *testing.T) {
+	const chunkSize = 4
+	tests := []struct {
+		name    string
+		size    int
+		entries int // Items stored for the value, chunks and manifest included
+	}{
+		{"empty", 0, 1},
+		{"under a chunk", 3, 1},
+		{"one chunk", chunkSize, 1},
+		{"just over a chunk", chunkSize + 1, 3},
+		{"many chunks", 10*chunkSize + 2, 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(CacheOpts{Capacity: 100, ChunkSize: chunkSize})
+			defer c.Close(context.Background())
+			value := make([]byte, tt.size)
+			for i := range value {
+				value[i] = byte('a' + i%26)
+			}
+
+			if err := c.Put([]byte("k"), value); err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			if got, err := c.Get([]byte("k")); err != nil || !bytes.Equal(got, value) {
+				t.Fatalf("Get = %q, %v; want %q", got, err, value)
+			}
+			if c.Len() != 1 {
+				t.Errorf("Len = %d, want 1", c.Len())
+			}
+			if n := c.items.Len(); n != tt.entries {
+				t.Errorf("%d entries stored, want %d", n, tt.entries)
+			}
+
+			// Overwriting with a small value drops the chunks
+			if err := c.Put([]byte("k"), []byte("v")); err != nil {
+				t.Fatalf("overwriting Put: %v", err)
+			}
+			if n := c.items.Len(); n != 1 {
+				t.Errorf("%d entries stored after overwriting, want 1", n)
+			}
+			if c.Delete([]byte("k")); c.items.Len() != 0 {
+				t.Errorf("%d entries stored after Delete, want 0", c.items.Len())
+			}
+		})
+	}
+}
+
+func TestChunkedValueEvictedAsAWhole(t *testing.T) {
+	c := NewCache(CacheOpts{Capacity: 6, ChunkSize: 4})
+	defer c.Close(context.Background())
+	c.Put([]byte("big"), []byte("0123456789")) // 3 chunks and a manifest
+	c.Put([]byte("a"), []byte("a"))
+	c.Put([]byte("b"), []byte("b"))
+	c.Put([]byte("c"), []byte("c")) // Evicts the oldest chunk, so the whole value
+
+	if c.Has([]byte("big")) {
+		t.Error("chunked value survived the eviction of one of its chunks")
+	}
+	if c.Len() != 3 {
+		t.Errorf("Len = %d, want 3", c.Len())
+	}
+	if err := c.Put([]byte("huge"), bytes.Repeat([]byte("x"), 40)); !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("Put of more chunks than fit = %v, want ErrValueTooLarge", err)
+	}
+}
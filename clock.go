@@ -0,0 +1,27 @@
+package cache
+
+import "time"
+
+// clockLoop advances the coarse clock every ClockResolution
+func (c *Cache) clockLoop(done <-chan struct{}) {
+	ticker := time.NewTicker(c.CacheOpts.ClockResolution)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case t := <-ticker.C:
+			c.clock.Store(t.UnixNano())
+		}
+	}
+}
+
+// now returns the current time, read from the coarse clock when
+// ClockResolution is set
+func (c *Cache) now() time.Time {
+	if c.CacheOpts.ClockResolution <= 0 {
+		return time.Now()
+	}
+	return time.Unix(0, c.clock.Load())
+}
@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"maps"
+	"time"
+)
+
+// Clone returns a new cache with the same options and a copy of the items,
+// including their TTLs and recency, held in a store of its own. Counters
+// start from zero.
+func (c *Cache) Clone() *Cache {
+	// Created before locking c, since joining c's group must not wait on a
+	// reclaim that is waiting on c. Reconfigure changes the options under
+	// the lock, so they are copied under it first.
+	c.mu.RLock()
+	opts := c.CacheOpts
+	c.mu.RUnlock()
+	clone := NewCache(opts)
+	// The clone's background workers are already running
+	clone.mu.Lock()
+	defer clone.mu.Unlock()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	c.items.Iterate(func(key string, value []byte) bool {
+		clone.items.Set(key, value)
+		return true
+	})
+	c.policy.Iterate(func(key string) bool {
+		clone.policy.RecordInsert(key) // Replaying the eviction order reproduces LRU state
+		return true
+	})
+	clone.timestamps = maps.Clone(c.timestamps)
+	clone.ttls = maps.Clone(c.ttls)
+	clone.chunks = maps.Clone(c.chunks)
+	clone.chunkOwner = maps.Clone(c.chunkOwner)
+	clone.nsBytes = maps.Clone(c.nsBytes)
+	clone.bytes.Store(c.bytes.Load())
+	clone.entries.Store(c.entries.Load())
+	if clone.member != nil {
+		clone.CacheOpts.Group.add(clone.member, clone.bytes.Load())
+	}
+	clone.generations = maps.Clone(c.generations)
+	clone.checksums = maps.Clone(c.checksums)
+	clone.nsGens = maps.Clone(c.nsGens)
+	clone.tombstones = maps.Clone(c.tombstones)
+	clone.versions, clone.lastVersion = maps.Clone(c.versions), c.lastVersion
+	for key, deps := range c.dependencies {
+		clone.dependencies[key] = maps.Clone(deps)
+	}
+	for key, dependents := range c.dependents {
+		clone.dependents[key] = maps.Clone(dependents)
+	}
+	clone.keySeed, clone.fpSeed = c.keySeed, c.fpSeed
+	clone.fingerprints = maps.Clone(c.fingerprints)
+	clone.items.Iterate(func(key string, _ []byte) bool {
+		if _, isChunk := clone.chunkOwner[key]; !isChunk {
+			clone.reschedule(key)
+		}
+		return true
+	})
+	return clone
+}
+
+// mergedItem is an item copied out of the other cache during a Merge
+type mergedItem struct {
+	key         string
+	value       []byte
+	written     time.Time
+	ttl         time.Duration
+	fingerprint uint64
+}
+
+// Merge copies the unexpired items of other into the cache, keeping their
+// write times so TTLs carry over. Merged items become the most recently used
+// in other's recency order. For keys present in both caches conflictFn picks
+// the value to keep; if it is nil other's value wins. Caches that hash keys
+// can only be merged with their clones, since digests depend on a per-cache seed.
+func (c *Cache) Merge(other *Cache, conflictFn func(key string, ours, theirs []byte) []byte) error {
+	if c.CacheOpts.HashKeys != other.CacheOpts.HashKeys ||
+		(c.CacheOpts.HashKeys && c.keySeed != other.keySeed) {
+		return ErrIncompatibleCache
+	}
+
+	other.mu.RLock()
+	var merged []mergedItem
+	other.policy.Iterate(func(key string) bool {
+		if _, isChunk := other.chunkOwner[key]; isChunk || other.expired(key) {
+			return true
+		}
+		value, err := other.decoded(key)
+		if err != nil {
+			return true
+		}
+		merged = append(merged, mergedItem{
+			key:         key,
+			value:       value,
+			written:     other.timestamps[key],
+			ttl:         other.ttls[key],
+			fingerprint: other.fingerprints[key],
+		})
+		return true
+	})
+	other.mu.RUnlock()
+
+	defer c.reclaimGroup() // Runs after unlocking
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	if c.frozen {
+		return c.frozenError()
+	}
+	for _, item := range merged {
+		if _, found := c.items.Get(item.key); found && !c.expired(item.key) {
+			if conflictFn != nil {
+				item.value = conflictFn(item.key, c.plain(item.key, c.valueOf(item.key)), item.value)
+			}
+			if c.timestamps[item.key].After(item.written) {
+				item.written = c.timestamps[item.key]
+			}
+		}
+		if err := c.put(item.key, item.value); err != nil {
+			return err
+		}
+		c.setWritten(item.key, item.written)
+		if item.ttl > 0 {
+			c.setTTL(item.key, item.ttl)
+		}
+		if c.CacheOpts.HashKeys {
+			c.fingerprints[item.key] = item.fingerprint
+		}
+	}
+	return nil
+}
+
+// setWritten sets the write time of an item and its chunks
+func (c *Cache) setWritten(key string, t time.Time) {
+	c.timestamps[key] = t
+	for _, chunkKey := range c.chunks[key] {
+		c.timestamps[chunkKey] = t
+	}
+	c.reschedule(key)
+}
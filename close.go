@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+)
+
+// Close stops the cache's background workers and waits for them to finish
+// flushing their work, or for ctx to be done, then writes the final snapshot
+// to SnapshotOnClose. Every subsequent operation on the cache returns
+// ErrClosed, or does nothing if it cannot fail.
+func (c *Cache) Close(ctx context.Context) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	var final *Snapshot
+	if c.CacheOpts.SnapshotOnClose != nil {
+		final = c.snapshot() // No write can slip in before closing
+	}
+	c.closed = true
+	close(c.done)
+	c.freeRoom()
+	c.mu.Unlock()
+	if c.member != nil {
+		c.CacheOpts.Group.leave(c)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		c.workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if final != nil {
+		if _, err := final.WriteTo(c.CacheOpts.SnapshotOnClose); err != nil {
+			return fmt.Errorf("writing final snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+// startWorker runs fn in a background goroutine tracked by Close. fn must
+// return once done is closed.
+func (c *Cache) startWorker(fn func(done <-chan struct{})) {
+	c.workers.Add(1)
+	go func() {
+		defer c.workers.Done()
+		fn(c.done)
+	}()
+}
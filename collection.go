@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+)
+
+// Lists and sets are stored as values holding their items one after the
+// other, each prefixed with its length as a uvarint. Set members are kept
+// sorted and unique.
+
+// ListPush appends item to the list stored under key, creating the list if
+// the key holds no value. With maxLen > 0 the oldest items are dropped to
+// keep at most maxLen. Concurrent pushes to the same list are atomic.
+func (c *Cache) ListPush(key, item []byte, maxLen int) error {
+	return c.modify(key, len(item)+binary.MaxVarintLen64, func(old []byte, _ bool) ([]byte, error) {
+		items, err := decodeItems(old)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if maxLen > 0 && len(items) > maxLen {
+			items = items[len(items)-maxLen:]
+		}
+		return encodeItems(items), nil
+	})
+}
+
+// List retrieves the items of the list stored under key, oldest first. The
+// items must not be modified.
+func (c *Cache) List(key []byte) ([][]byte, error) {
+	value, err := c.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return decodeItems(value)
+}
+
+// SetAdd adds members to the set stored under key, creating the set if the
+// key holds no value. Concurrent additions to the same set are atomic.
+func (c *Cache) SetAdd(key []byte, members ...[]byte) error {
+	size := 0
+	for _, m := range members {
+		size += len(m) + binary.MaxVarintLen64
+	}
+	return c.modify(key, size, func(old []byte, _ bool) ([]byte, error) {
+		set, err := decodeItems(old)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			if i, ok := slices.BinarySearchFunc(set, m, bytes.Compare); !ok {
+				set = slices.Insert(set, i, m)
+			}
+		}
+		return encodeItems(set), nil
+	})
+}
+
+// SetRemove removes members from the set stored under key. Removing from a
+// missing set does nothing.
+func (c *Cache) SetRemove(key []byte, members ...[]byte) error {
+	err := c.Update(key, func(old []byte) ([]byte, error) {
+		set, err := decodeItems(old)
+		if err != nil {
+			return nil, err
+		}
+		set = slices.DeleteFunc(set, func(m []byte) bool {
+			return slices.ContainsFunc(members, func(r []byte) bool { return bytes.Equal(m, r) })
+		})
+		return encodeItems(set), nil
+	})
+	if isMiss(err) {
+		return nil
+	}
+	return err
+}
+
+// SetMembers retrieves the members of the set stored under key in byte
+// order. The members must not be modified.
+func (c *Cache) SetMembers(key []byte) ([][]byte, error) {
+	value, err := c.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return decodeItems(value)
+}
+
+// SetContains reports whether member is in the set stored under key
+func (c *Cache) SetContains(key, member []byte) (bool, error) {
+	set, err := c.SetMembers(key)
+	if err != nil {
+		return false, err
+	}
+	_, ok := slices.BinarySearchFunc(set, member, bytes.Compare)
+	return ok, nil
+}
+
+// encodeItems encodes the items of a list or set
+func encodeItems(items [][]byte) []byte {
+	size := 0
+	for _, item := range items {
+		size += binary.MaxVarintLen64 + len(item)
+	}
+	b := make([]byte, 0, size)
+	for _, item := range items {
+		b = binary.AppendUvarint(b, uint64(len(item)))
+		b = append(b, item...)
+	}
+	return b
+}
+
+// decodeItems decodes the items of a list or set without copying them
+func decodeItems(b []byte) ([][]byte, error) {
+	var items [][]byte
+	for len(b) > 0 {
+		size, n := binary.Uvarint(b)
+		if n <= 0 || size > uint64(len(b)-n) {
+			return nil, ErrNotCollection
+		}
+		b = b[n:]
+		items = append(items, b[:size:size])
+		b = b[size:]
+	}
+	return items, nil
+}
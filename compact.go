@@ -0,0 +1,75 @@
+package cache
+
+// Compacter is implemented by stores and policies that can shrink their
+// internal structures to fit their current contents, see Compact
+type Compacter interface {
+	Compact()
+}
+
+// Compact rebuilds the cache's maps to fit its current contents. Go maps
+// never shrink, so after mass deletions or a shift in traffic they keep the
+// memory of their largest size until compacted. Stores and policies other
+// than the defaults are compacted if they implement Compacter. Compact holds
+// the cache lock for time proportional to the number of entries.
+func (c *Cache) Compact() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+	if s, ok := c.items.(mapStore); ok {
+		c.items = mapStore(compactMap(s))
+	} else if s, ok := c.items.(Compacter); ok {
+		s.Compact()
+	}
+	if p, ok := c.policy.(Compacter); ok {
+		p.Compact()
+	}
+	if c.wheel != nil {
+		c.wheel.where = compactMap(c.wheel.where)
+	}
+	c.timestamps = compactMap(c.timestamps)
+	c.ttls = compactMap(c.ttls)
+	c.chunks = compactMap(c.chunks)
+	c.chunkOwner = compactMap(c.chunkOwner)
+	c.generations = compactMap(c.generations)
+	c.checksums = compactMap(c.checksums)
+	c.promotions = compactMap(c.promotions)
+	c.fingerprints = compactMap(c.fingerprints)
+	c.reservations = compactMap(c.reservations)
+	c.dependencies = compactMap(c.dependencies)
+	c.dependents = compactMap(c.dependents)
+	c.tombstones = compactMap(c.tombstones)
+	c.versions = compactMap(c.versions)
+	if c.scanIndex != nil {
+		for i, bucket := range c.scanIndex.buckets {
+			c.scanIndex.buckets[i] = compactMap(bucket)
+		}
+	}
+
+	c.loadMu.Lock()
+	now := c.now()
+	for key, failed := range c.loadErrors {
+		if !now.Before(failed.expires) {
+			delete(c.loadErrors, key)
+		}
+	}
+	c.loadErrors = compactMap(c.loadErrors)
+	c.loadMu.Unlock()
+}
+
+// Compact rebuilds the node index and drops the nodes kept for reuse
+func (p *lruPolicy) Compact() {
+	p.nodes = compactMap(p.nodes)
+	p.free, p.nfree = nil, 0
+}
+
+// compactMap copies m into a map sized for its current length
+func compactMap[K comparable, V any](m map[K]V) map[K]V {
+	compacted := make(map[K]V, len(m))
+	for k, v := range m {
+		compacted[k] = v
+	}
+	return compacted
+}
@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Config holds the options that can be changed while a cache is running.
+// Nil fields are left unchanged.
+type Config struct {
+	Capacity      *int      `json:"capacity,omitempty"`
+	MaxBytes      *int64    `json:"max_bytes,omitempty"`
+	TTL           *Duration `json:"ttl,omitempty"`
+	LowWatermark  *float64  `json:"low_watermark,omitempty"`
+	EvictionBatch *float64  `json:"eviction_batch,omitempty"`
+}
+
+// Duration is a time.Duration encoded in JSON as a string such as "5m"
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// LoadConfig reads a Config from a JSON file
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields() // Catch misspelled options instead of ignoring them
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("%w: %s: %v", ErrInvalidConfig, path, err)
+	}
+	return cfg, nil
+}
+
+// validate checks the values of a Config
+func (cfg Config) validate() error {
+	switch {
+	case cfg.Capacity != nil && *cfg.Capacity < 0:
+		return fmt.Errorf("%w: negative capacity", ErrInvalidConfig)
+	case cfg.MaxBytes != nil && *cfg.MaxBytes < 0:
+		return fmt.Errorf("%w: negative max_bytes", ErrInvalidConfig)
+	case cfg.TTL != nil && *cfg.TTL < 0:
+		return fmt.Errorf("%w: negative ttl", ErrInvalidConfig)
+	case cfg.LowWatermark != nil && (*cfg.LowWatermark < 0 || *cfg.LowWatermark >= 1):
+		return fmt.Errorf("%w: low_watermark not in [0, 1)", ErrInvalidConfig)
+	case cfg.EvictionBatch != nil && (*cfg.EvictionBatch < 0 || *cfg.EvictionBatch > 1):
+		return fmt.Errorf("%w: eviction_batch not in [0, 1]", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// Reconfigure applies cfg to the running cache. A smaller Capacity or
+// MaxBytes evicts down to the new limits at once, and a new TTL applies to
+// every item without a TTL of its own, counting from when it was written.
+func (c *Cache) Reconfigure(cfg Config) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	if cfg.Capacity != nil {
+		c.CacheOpts.Capacity = *cfg.Capacity
+	}
+	if cfg.MaxBytes != nil {
+		c.CacheOpts.MaxBytes = *cfg.MaxBytes
+	}
+	if cfg.EvictionBatch != nil {
+		c.CacheOpts.EvictionBatch = *cfg.EvictionBatch
+	}
+	if cfg.LowWatermark != nil {
+		if !c.trimming && *cfg.LowWatermark > 0 {
+			c.trimming = true
+			c.lastTrim = time.Now() // Health counts the worker's first interval from here
+			c.startWorker(c.trimLoop)
+		}
+		c.CacheOpts.LowWatermark = *cfg.LowWatermark
+	}
+	if cfg.TTL != nil && time.Duration(*cfg.TTL) != c.CacheOpts.TTL {
+		c.CacheOpts.TTL = time.Duration(*cfg.TTL)
+		c.items.Iterate(func(key string, _ []byte) bool {
+			if _, isChunk := c.chunkOwner[key]; !isChunk {
+				c.reschedule(key)
+			}
+			return true
+		})
+	}
+	c.makeRoom("", 0, 0)
+	c.freeRoom() // Puts waiting for room may fit under the new limits
+	return nil
+}
+
+// defaultWatchInterval is how often WatchConfig polls without a positive interval
+const defaultWatchInterval = 10 * time.Second
+
+// WatchConfig applies the Config in a JSON file and then polls the file
+// every interval, 10 seconds if it is not positive, reapplying it whenever
+// it changes, until the cache is closed. Errors reading or applying a
+// changed file are reported to the ErrorHandler and leave the previous
+// configuration in place.
+func (c *Cache) WatchConfig(path string, interval time.Duration) error {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	if err := c.Reconfigure(cfg); err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	c.startWorker(func(done <-chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		modified, size := info.ModTime(), info.Size()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				info, err := os.Stat(path)
+				if err != nil {
+					c.reportError(fmt.Errorf("watching config: %w", err))
+					continue
+				}
+				if info.ModTime().Equal(modified) && info.Size() == size {
+					continue
+				}
+				modified, size = info.ModTime(), info.Size()
+				cfg, err := LoadConfig(path)
+				if err == nil {
+					err = c.Reconfigure(cfg)
+				}
+				if err != nil {
+					c.reportError(fmt.Errorf("reloading config: %w", err))
+				}
+			}
+		}
+	})
+	return nil
+}
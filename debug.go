@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"fmt"
+	"maps"
+	"time"
+)
+
+// entryOverhead is a rough estimate of the bookkeeping bytes of an entry
+// besides its key and value: map slots, timestamps and the policy's node
+const entryOverhead = 160
+
+// DebugStater is implemented by policies and stores that can describe their
+// internal state for DebugReport
+type DebugStater interface {
+	DebugState() any
+}
+
+// DebugReport is a consistent view of a cache's configuration and state, to
+// attach to bug reports
+type DebugReport struct {
+	Taken   time.Time
+	Options CacheOpts // Without secrets such as KeySecret and Transform, keys redacted
+	Metrics Metrics
+
+	Len            int
+	Bytes          int64            // Bytes of stored values
+	MemoryEstimate int64            // Rough estimate of the memory used, including keys and bookkeeping
+	Namespaces     map[string]int64 // Bytes stored per namespace
+	Frozen         bool
+	Closed         bool
+
+	Policy      string // Type of the eviction policy
+	PolicyState any    // Set if the policy implements DebugStater
+	Store       string // Type of the store
+	StoreState  any    // Set if the store implements DebugStater
+
+	SketchResets    int       // Times the frequency sketch was aged
+	DoorkeeperReset time.Time // Last reset of the doorkeeper
+	LastExpiry      time.Time // Last run of the expiry worker
+	LastTrim        time.Time // Last run of the watermark trimmer
+}
+
+// DebugReport returns a snapshot of the cache's configuration and state. It
+// visits every key to estimate memory use, so it is not meant to be called
+// on a hot path.
+func (c *Cache) DebugReport() DebugReport {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	r := DebugReport{
+		Taken:      time.Now(),
+		Options:    c.reportedOptions(),
+		Metrics:    c.metrics(),
+		Len:        c.Len(),
+		Bytes:      c.SizeBytes(),
+		Namespaces: maps.Clone(c.nsBytes),
+		Frozen:     c.frozen,
+		Closed:     c.closed,
+		Policy:     fmt.Sprintf("%T", c.policy),
+		Store:      fmt.Sprintf("%T", c.items),
+		LastExpiry: c.lastExpiry,
+		LastTrim:   c.lastTrim,
+	}
+	if s, ok := c.policy.(DebugStater); ok {
+		r.PolicyState = s.DebugState()
+	}
+	if s, ok := c.items.(DebugStater); ok {
+		r.StoreState = s.DebugState()
+	}
+	if c.sketch != nil {
+		r.SketchResets = c.sketch.resets
+	}
+	if c.doorkeeper != nil {
+		r.DoorkeeperReset = c.doorkeeper.reset
+	}
+
+	r.MemoryEstimate = r.Bytes
+	c.items.Iterate(func(key string, _ []byte) bool {
+		r.MemoryEstimate += int64(len(key)) + entryOverhead
+		return true
+	})
+	return r
+}
+
+// reportedOptions returns the cache's options without their secrets: the
+// KeySecret, and the transformers, which may hold encryption keys. Metric
+// keys are redacted like every other key the cache exposes.
+func (c *Cache) reportedOptions() CacheOpts {
+	opts := c.CacheOpts
+	opts.KeySecret = nil
+	opts.Transform = nil
+	if opts.MetricKeys != nil {
+		opts.MetricKeys = make([][]byte, len(c.CacheOpts.MetricKeys))
+		for i, key := range c.CacheOpts.MetricKeys {
+			opts.MetricKeys[i] = []byte(c.displayKey(key))
+		}
+	}
+	if opts.Namespaces != nil {
+		opts.Namespaces = maps.Clone(opts.Namespaces)
+		for ns, nsOpts := range opts.Namespaces {
+			nsOpts.Transform = nil
+			opts.Namespaces[ns] = nsOpts
+		}
+	}
+	return opts
+}
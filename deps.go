@@ -0,0 +1,115 @@
+package cache
+
+import "github.com/dhyanio/discache/util"
+
+// keySet is a set of storage keys
+type keySet map[string]struct{}
+
+// DependOn makes the item under key depend on the items under each of on:
+// removing or expiring any of them also removes it, and so on down the
+// graph. Both the item and its dependencies must be stored and unexpired,
+// or it fails with a *util.KeyNotFoundError. A dependency that would close
+// a cycle fails with ErrDependencyCycle. Overwriting an item drops its own
+// dependencies.
+func (c *Cache) DependOn(key []byte, on ...[]byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	strKey := c.storageKey(key)
+	if !c.live(strKey, key) {
+		return &util.KeyNotFoundError{Key: string(key)}
+	}
+	deps := make([]string, len(on))
+	for i, dep := range on {
+		deps[i] = c.storageKey(dep)
+		if !c.live(deps[i], dep) {
+			return &util.KeyNotFoundError{Key: string(dep)}
+		}
+		if c.dependsOn(deps[i], strKey) {
+			return ErrDependencyCycle
+		}
+	}
+	for _, dep := range deps {
+		c.link(strKey, dep)
+	}
+	return nil
+}
+
+// live reports whether an unexpired item is stored under key
+func (c *Cache) live(strKey string, key []byte) bool {
+	_, found := c.items.Get(strKey)
+	return found && !c.collides(strKey, key) && !c.expired(strKey)
+}
+
+// dependsOn reports whether key is, or transitively depends on, dep
+func (c *Cache) dependsOn(key, dep string) bool {
+	return c.anyDependency(key, func(k string) bool { return k == dep })
+}
+
+// anyDependency reports whether fn holds for key or any item it transitively
+// depends on, visiting each item once however many paths lead to it
+func (c *Cache) anyDependency(key string, fn func(key string) bool) bool {
+	visited := keySet{key: {}}
+	stack := []string{key}
+	for len(stack) > 0 {
+		k := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if fn(k) {
+			return true
+		}
+		for next := range c.dependencies[k] {
+			if _, ok := visited[next]; !ok {
+				visited[next] = struct{}{}
+				stack = append(stack, next)
+			}
+		}
+	}
+	return false
+}
+
+// link records that key depends on dep
+func (c *Cache) link(key, dep string) {
+	if c.dependencies[key] == nil {
+		c.dependencies[key] = make(keySet)
+	}
+	c.dependencies[key][dep] = struct{}{}
+	if c.dependents[dep] == nil {
+		c.dependents[dep] = make(keySet)
+	}
+	c.dependents[dep][key] = struct{}{}
+}
+
+// dependencyExpired reports whether any item that key transitively depends
+// on expired, so dependents miss even before an expired dependency is removed
+func (c *Cache) dependencyExpired(key string) bool {
+	if len(c.dependencies[key]) == 0 {
+		return false
+	}
+	return c.anyDependency(key, func(k string) bool { return k != key && c.expiredItself(k) })
+}
+
+// dropDependencies unlinks a removed item from the items it depends on and
+// expires the items depending on it
+func (c *Cache) dropDependencies(key string) {
+	for dep := range c.dependencies[key] {
+		delete(c.dependents[dep], key)
+		if len(c.dependents[dep]) == 0 {
+			delete(c.dependents, dep)
+		}
+	}
+	delete(c.dependencies, key)
+
+	dependents := c.dependents[key]
+	delete(c.dependents, key)
+	for dependent := range dependents {
+		if _, found := c.items.Get(dependent); found { // Not removed through another dependency
+			c.trace(dependent, "evict", "dependency")
+			c.queueExpiration(dependent)
+			c.remove(dependent)
+			c.expirations++
+		}
+	}
+}
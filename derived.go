@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/dhyanio/discache/util"
+)
+
+// PutDerived inserts an item derived from the item under parentKey, such as
+// a projection of one of its fields. The derived item expires no later than
+// its parent and depends on it as with DependOn, so it is removed whenever
+// the parent is deleted, evicted, expired or overwritten. Deriving from a
+// missing or expired parent fails with a *util.KeyNotFoundError.
+func (c *Cache) PutDerived(parentKey, key, value []byte) error {
+	if err := c.limit(context.Background(), key); err != nil {
+		return err
+	}
+
+	defer c.reclaimGroup() // Runs after unlocking
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	if c.frozen {
+		return c.frozenError()
+	}
+
+	parent, strKey := c.storageKey(parentKey), c.storageKey(key)
+	if !c.live(parent, parentKey) {
+		return &util.KeyNotFoundError{Key: string(parentKey)}
+	}
+	if c.dependsOn(parent, strKey) {
+		return ErrDependencyCycle
+	}
+	if err := c.awaitRoom(strKey, len(value)); err != nil {
+		return err
+	}
+	if err := c.checkReservation(strKey, 0); err != nil {
+		return err
+	}
+	if err := c.checkCollision(strKey, key); err != nil {
+		return err
+	}
+	if err := c.put(strKey, value); err != nil {
+		return err
+	}
+	if !c.live(parent, parentKey) {
+		c.remove(strKey) // The parent was evicted to make room
+		return &util.KeyNotFoundError{Key: string(parentKey)}
+	}
+	if ttl := c.ttlOf(parent); ttl > 0 {
+		remaining := max(c.timestamps[parent].Add(ttl).Sub(c.now()), time.Nanosecond)
+		if own := c.ttlOf(strKey); own <= 0 || own > remaining {
+			c.setTTL(strKey, remaining)
+		}
+	}
+	c.link(strKey, parent)
+	c.setFingerprint(strKey, key)
+	return nil
+}
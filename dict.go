@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"slices"
+	"sync"
+)
+
+const (
+	dictKmer    = 8         // Length of the substrings counted when training a dictionary
+	dictSegment = 64        // Length of the sample segments a dictionary is made of
+	maxDictSize = 32 * 1024 // Longest dictionary deflate can refer back to
+)
+
+// TrainDictionary builds a raw content dictionary of up to size bytes from
+// sample values, for FlateDictTransformer. It keeps the sample segments richest
+// in substrings shared by several samples, e.g. the field names and common
+// values of JSON documents, most useful last. Samples should be typical of
+// the values the dictionary will compress; a few hundred are usually enough.
+func TrainDictionary(samples [][]byte, size int) []byte {
+	size = min(size, maxDictSize)
+
+	// Count the samples every substring appears in
+	freq := make(map[string]int)
+	for _, s := range samples {
+		seen := make(map[string]bool)
+		for i := 0; i+dictKmer <= len(s); i++ {
+			if kmer := string(s[i : i+dictKmer]); !seen[kmer] {
+				seen[kmer] = true
+				freq[kmer]++
+			}
+		}
+	}
+
+	type segment struct {
+		data  []byte
+		score int
+	}
+	// score sums the substrings of a segment that appear in other samples
+	score := func(data []byte) int {
+		total := 0
+		for i := 0; i+dictKmer <= len(data); i++ {
+			if n := freq[string(data[i:i+dictKmer])]; n > 1 {
+				total += n
+			}
+		}
+		return total
+	}
+	var segments []segment
+	for _, s := range samples {
+		for start := 0; start+dictKmer <= len(s); start += dictSegment {
+			data := s[start:min(start+dictSegment, len(s))]
+			segments = append(segments, segment{data: data, score: score(data)})
+		}
+	}
+	slices.SortStableFunc(segments, func(a, b segment) int { return b.score - a.score })
+
+	// Take the best segments, scoring each again without the substrings
+	// already in the dictionary so repetitive samples do not fill it
+	var picked [][]byte
+	total := 0
+	for _, seg := range segments {
+		if total >= size || seg.score == 0 {
+			break
+		}
+		if score(seg.data) == 0 {
+			continue
+		}
+		for i := 0; i+dictKmer <= len(seg.data); i++ {
+			delete(freq, string(seg.data[i:i+dictKmer]))
+		}
+		picked = append(picked, seg.data)
+		total += len(seg.data)
+	}
+
+	dict := make([]byte, 0, total)
+	for i := len(picked) - 1; i >= 0; i-- {
+		dict = append(dict, picked[i]...)
+	}
+	return dict[max(len(dict)-size, 0):]
+}
+
+// flateDictTransformer compresses values with deflate and a preset dictionary
+type flateDictTransformer struct {
+	dict    []byte
+	level   int
+	writers sync.Pool // *flate.Writer, reused since each allocates hundreds of kilobytes
+	readers sync.Pool // io.ReadCloser implementing flate.Resetter
+}
+
+// FlateDictTransformer returns a Transformer compressing values with deflate
+// (compress/flate) at the given level, primed with a dictionary from
+// TrainDictionary. Small values compress far better than without one, as
+// they share most of their content with the dictionary. Use level 7 or
+// above, such as flate.BestCompression: current Go releases ignore the
+// dictionary at lower levels. Values must be decoded with the same
+// dictionary, so set it per namespace through NamespaceOpts.Transform and
+// keep it for as long as values encoded with it are stored.
+//
+// This is not zstd: the package only depends on the standard library, which
+// has no zstd encoder. Deflate compresses less than zstd with a dictionary,
+// only refers back 32 KiB into the dictionary, and its output cannot be read
+// by zstd decoders, nor can it read zstd frames written elsewhere. Use a
+// zstd library through a custom Transformer where that matters.
+func FlateDictTransformer(dict []byte, level int) (Transformer, error) {
+	if _, err := flate.NewWriterDict(io.Discard, level, dict); err != nil {
+		return nil, err
+	}
+	return &flateDictTransformer{dict: bytes.Clone(dict), level: level}, nil
+}
+
+func (t *flateDictTransformer) Encode(value []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, ok := t.writers.Get().(*flate.Writer)
+	if ok {
+		w.Reset(&buf)
+	} else {
+		var err error
+		if w, err = flate.NewWriterDict(&buf, t.level, t.dict); err != nil {
+			return nil, err
+		}
+	}
+	defer t.writers.Put(w)
+	if _, err := w.Write(value); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (t *flateDictTransformer) Decode(value []byte) ([]byte, error) {
+	r, ok := t.readers.Get().(io.ReadCloser)
+	if ok {
+		if err := r.(flate.Resetter).Reset(bytes.NewReader(value), t.dict); err != nil {
+			return nil, err
+		}
+	} else {
+		r = flate.NewReaderDict(bytes.NewReader(value), t.dict)
+	}
+	defer t.readers.Put(r)
+	return io.ReadAll(r)
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"compress/flate"
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestFlateDictTransformer(t *testing.T) {
+	record := func(i int) []byte {
+		return []byte(fmt.Sprintf(`{"user_id":%d,"display_name":"user-%d","status":"active","preferences":{"theme":"dark","language":"en-US"}}`, i, i*7))
+	}
+	var samples [][]byte
+	for i := 0; i < 200; i++ {
+		samples = append(samples, record(i))
+	}
+	dict := TrainDictionary(samples, 4096)
+	if len(dict) == 0 || len(dict) > 4096 {
+		t.Fatalf("TrainDictionary returned %d bytes, want 1 to 4096", len(dict))
+	}
+
+	tests := []struct {
+		level      int
+		compresses bool // Whether the level makes use of the dictionary
+		wantErr    bool
+	}{
+		{flate.BestSpeed, false, false},
+		{flate.DefaultCompression, false, false},
+		{7, true, false},
+		{flate.BestCompression, true, false},
+		{42, false, true},
+	}
+	value := record(9999)
+	for _, tt := range tests {
+		tr, err := FlateDictTransformer(dict, tt.level)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("level %d: FlateDictTransformer error %v, want error %v", tt.level, err, tt.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		withDict, err := tr.Encode(value)
+		if err != nil {
+			t.Fatalf("level %d: Encode: %v", tt.level, err)
+		}
+		withoutDict, _ := FlateDictTransformer(nil, tt.level)
+		plain, _ := withoutDict.Encode(value)
+		if tt.compresses && len(withDict) >= len(plain) {
+			t.Errorf("level %d: %d bytes with the dictionary, %d without", tt.level, len(withDict), len(plain))
+		}
+
+		c := NewCache(CacheOpts{Capacity: 10, Transform: tr})
+		for i := 0; i < 3; i++ { // Reuses pooled writers and readers
+			if err := c.Put([]byte("k"), value); err != nil {
+				t.Fatalf("level %d: Put: %v", tt.level, err)
+			}
+			if got, err := c.Get([]byte("k")); err != nil || string(got) != string(value) {
+				t.Fatalf("level %d: Get = %q, %v", tt.level, got, err)
+			}
+		}
+		c.Close(context.Background())
+	}
+}
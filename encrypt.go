@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+)
+
+// aesTransformer seals values with AES-GCM
+type aesTransformer struct {
+	aeads []cipher.AEAD // The first seals, any opens
+}
+
+// AESTransformer returns a Transformer encrypting values with AES-GCM, so
+// values sit encrypted in memory and do not show up in core dumps or heap
+// profiles; only the copies returned by reads are plaintext. Keys must be
+// 16, 24 or 32 bytes. Values are encrypted with the first key and decrypted
+// with whichever key opens them, so keys can be rotated by prepending a new
+// one and dropping the old one once its values have been rewritten or have
+// expired.
+func AESTransformer(keys ...[]byte) (Transformer, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("cache: no AES key")
+	}
+	t := aesTransformer{aeads: make([]cipher.AEAD, len(keys))}
+	for i, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		if t.aeads[i], err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Encode seals a value as a random nonce followed by the ciphertext
+func (t aesTransformer) Encode(value []byte) ([]byte, error) {
+	aead := t.aeads[0]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(value)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, value, nil), nil
+}
+
+func (t aesTransformer) Decode(value []byte) ([]byte, error) {
+	err := errors.New("encrypted value too short")
+	for _, aead := range t.aeads {
+		if len(value) < aead.NonceSize() {
+			continue
+		}
+		var plain []byte
+		nonce, ciphertext := value[:aead.NonceSize()], value[aead.NonceSize():]
+		if plain, err = aead.Open(nil, nonce, ciphertext, nil); err == nil {
+			return plain, nil
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAESTransformerKeyRotation(t *testing.T) {
+	oldKey, newKey := bytes.Repeat([]byte{1}, 16), bytes.Repeat([]byte{2}, 32)
+	tests := []struct {
+		name       string
+		writeKeys  [][]byte
+		readKeys   [][]byte
+		decodeable bool
+	}{
+		{name: "same key", writeKeys: [][]byte{oldKey}, readKeys: [][]byte{oldKey}, decodeable: true},
+		{name: "new key prepended", writeKeys: [][]byte{oldKey}, readKeys: [][]byte{newKey, oldKey}, decodeable: true},
+		{name: "old key dropped", writeKeys: [][]byte{oldKey}, readKeys: [][]byte{newKey}},
+		{name: "written with new key", writeKeys: [][]byte{newKey, oldKey}, readKeys: [][]byte{newKey}, decodeable: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			write, err := AESTransformer(tt.writeKeys...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			read, err := AESTransformer(tt.readKeys...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := NewCache(CacheOpts{Capacity: 10, Transform: write})
+			defer c.Close(context.Background())
+			c.Put([]byte("k"), []byte("secret"))
+			if bytes.Contains(c.valueOf("k"), []byte("secret")) {
+				t.Fatal("value stored in plaintext")
+			}
+			c.CacheOpts.Transform = read
+
+			got, err := c.Get([]byte("k"))
+			if tt.decodeable && (err != nil || string(got) != "secret") {
+				t.Errorf("Get = %q, %v, want %q", got, err, "secret")
+			}
+			if !tt.decodeable && !errors.Is(err, ErrCorrupted) {
+				t.Errorf("Get = %q, %v, want %v", got, err, ErrCorrupted)
+			}
+		})
+	}
+}
+
+func TestAESTransformerRejectsBadKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		keys [][]byte
+	}{
+		{name: "none"},
+		{name: "short", keys: [][]byte{make([]byte, 15)}},
+		{name: "one bad", keys: [][]byte{make([]byte, 32), make([]byte, 20)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := AESTransformer(tt.keys...); err == nil {
+				t.Error("AESTransformer = nil error, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// EntryInfo contains the metadata of a cached item
+type EntryInfo struct {
+	Namespace string
+	Written   time.Time // When the item was last written
+	Expires   time.Time // Zero if the item never expires
+	Size      int64     // Size of the value, including chunks
+	Version   uint64    // Hybrid logical timestamp of the last write, see PutVersioned
+}
+
+// DeleteFunc removes every item for which fn returns true in a single locked
+// pass and returns how many were removed. fn must not call into the cache.
+func (c *Cache) DeleteFunc(fn func(key string, value []byte, meta EntryInfo) bool) int {
+	var matched []string
+	if c.CacheOpts.Audit != nil {
+		defer func(start time.Time) {
+			for _, key := range matched {
+				c.audit(context.Background(), AuditDelete, []byte(key), 0, start, "deleted", nil)
+			}
+		}(time.Now())
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed || c.frozen {
+		return 0
+	}
+	c.items.Iterate(func(key string, _ []byte) bool {
+		if _, isChunk := c.chunkOwner[key]; !isChunk && fn(key, c.plain(key, c.valueOf(key)), c.info(key)) {
+			matched = append(matched, key)
+		}
+		return true
+	})
+	for _, key := range matched {
+		c.remove(key)
+	}
+	return len(matched)
+}
+
+// valid consults the Validate hook about an item being read. A panicking
+// hook counts as rejecting the item.
+func (c *Cache) valid(key string) bool {
+	if c.CacheOpts.Validate == nil {
+		return true
+	}
+	ok := false
+	c.callback("Validate", func() {
+		ok = c.CacheOpts.Validate(key, c.plain(key, c.valueOf(key)), c.info(key))
+	})
+	return ok
+}
+
+// valueOf returns the value of an item, reassembling it if it is chunked
+func (c *Cache) valueOf(key string) []byte {
+	if chunkKeys, ok := c.chunks[key]; ok {
+		return c.joinChunks(chunkKeys)
+	}
+	return c.stored(key)
+}
+
+// info returns the metadata of an item
+func (c *Cache) info(key string) EntryInfo {
+	info := EntryInfo{
+		Namespace: c.namespaceOf(key),
+		Written:   c.timestamps[key],
+		Size:      c.sizeOf(key),
+		Version:   c.versions[key],
+	}
+	if ttl := c.ttlOf(key); ttl > 0 {
+		info.Expires = info.Written.Add(ttl)
+	}
+	if _, ok := c.chunks[key]; ok {
+		info.Size -= manifestSize
+	}
+	return info
+}
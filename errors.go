@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrValueTooLarge is returned when a value cannot fit in the cache even after evicting every other entry
+	ErrValueTooLarge = errors.New("cache: value too large")
+
+	// ErrFrozen is returned by writes to a frozen cache
+	ErrFrozen = errors.New("cache: frozen")
+
+	// ErrClosed is returned by operations on a closed cache
+	ErrClosed = errors.New("cache: closed")
+
+	// ErrLoadTimeout is returned by GetOrLoad when a load exceeds LoadTimeout and no stale value is stored
+	ErrLoadTimeout = errors.New("cache: load timed out")
+
+	// ErrRateLimited is returned when a namespace exceeds its rate limit
+	ErrRateLimited = errors.New("cache: rate limited")
+
+	// ErrQuotaExceeded is returned when a value is larger than its namespace's byte quota
+	ErrQuotaExceeded = errors.New("cache: quota exceeded")
+
+	// ErrKeyCollision is returned when a Put's key digest collides with another key's under CollisionReject
+	ErrKeyCollision = errors.New("cache: key digest collision")
+
+	// ErrCacheFull is returned by Puts to a full cache under FullReject or FullBlock
+	ErrCacheFull = errors.New("cache: full")
+
+	// ErrReserved is returned by writes to a key reserved by someone else
+	ErrReserved = errors.New("cache: key reserved")
+
+	// ErrCorrupted is returned by reads of an item whose value no longer matches its checksum
+	ErrCorrupted = errors.New("cache: value corrupted")
+
+	// ErrStalled is returned by Health when a background worker stopped running
+	ErrStalled = errors.New("cache: background worker stalled")
+
+	// ErrUnderPressure is returned by Ready when the cache is under more pressure than allowed
+	ErrUnderPressure = errors.New("cache: under pressure")
+
+	// ErrInvalidConfig is returned when a Config cannot be read or applied
+	ErrInvalidConfig = errors.New("cache: invalid config")
+
+	// ErrNotCollection is returned by list and set operations on a value that is not a list or set
+	ErrNotCollection = errors.New("cache: value is not a list or set")
+
+	// ErrDependencyCycle is returned when an item would depend on itself
+	ErrDependencyCycle = errors.New("cache: dependency cycle")
+
+	// ErrStaleFill is returned by fills of values read before their key was last deleted
+	ErrStaleFill = errors.New("cache: stale fill")
+
+	// ErrStaleWrite is returned by PutVersioned under LastWriteWins when a newer version is stored
+	ErrStaleWrite = errors.New("cache: stale write")
+
+	// ErrNegativeOffset is returned by GetRange for a negative offset
+	ErrNegativeOffset = errors.New("cache: negative offset")
+
+	// ErrOverReserved is returned by ReserveCapacity when reservations would exceed MaxBytes
+	ErrOverReserved = errors.New("cache: capacity over-reserved")
+
+	// ErrUnsupportedKey is returned for keys DefaultKeyCodec cannot encode
+	ErrUnsupportedKey = errors.New("cache: unsupported key type")
+
+	// ErrIncompatibleCache is returned when merging caches whose keys cannot be combined
+	ErrIncompatibleCache = errors.New("cache: incompatible cache")
+)
+
+// PanicError is reported to the ErrorHandler when a user callback panics
+type PanicError struct {
+	Callback string
+	Value    any
+	Stack    []byte
+}
+
+// Error implements the error interface
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("cache: %s panicked: %v", e.Callback, e.Value)
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultExpiryBuckets are the bucket bounds used by ExpiryReport when none are given
+var DefaultExpiryBuckets = []time.Duration{10 * time.Second, time.Minute, 10 * time.Minute, time.Hour}
+
+// ExpiryBucket counts the items expiring after the previous bucket's bound
+// and up to Within from now
+type ExpiryBucket struct {
+	Within time.Duration
+	Items  int
+	Bytes  int64
+}
+
+// ExpiryReport describes when the items in the cache will expire
+type ExpiryReport struct {
+	Buckets []ExpiryBucket
+	Later   int // Items expiring after the last bucket
+	Never   int // Items without a TTL
+}
+
+// ExpiryReport counts the items expiring within each of the given bounds, or
+// DefaultExpiryBuckets, so expiry-driven load on the backing store can be anticipated
+func (c *Cache) ExpiryReport(bounds ...time.Duration) ExpiryReport {
+	if len(bounds) == 0 {
+		bounds = DefaultExpiryBuckets
+	}
+	bounds = append([]time.Duration(nil), bounds...)
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+
+	report := ExpiryReport{Buckets: make([]ExpiryBucket, len(bounds))}
+	for i, bound := range bounds {
+		report.Buckets[i].Within = bound
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return report
+	}
+	now := time.Now()
+	c.items.Iterate(func(key string, _ []byte) bool {
+		if _, isChunk := c.chunkOwner[key]; isChunk {
+			return true
+		}
+		info := c.info(key)
+		if info.Expires.IsZero() {
+			report.Never++
+			return true
+		}
+		remaining := info.Expires.Sub(now)
+		if remaining < 0 {
+			return true // Already expired, awaiting removal
+		}
+		i := sort.Search(len(bounds), func(i int) bool { return remaining <= bounds[i] })
+		if i == len(bounds) {
+			report.Later++
+			return true
+		}
+		report.Buckets[i].Items++
+		report.Buckets[i].Bytes += info.Size
+		return true
+	})
+	return report
+}
@@ -0,0 +1,36 @@
+package cache
+
+// Freeze makes the cache read-only. Writes are rejected with ErrFrozen, or
+// silently ignored if IgnoreFrozenWrites is set, until Thaw is called.
+// Reads keep updating recency and expiring items.
+func (c *Cache) Freeze() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.frozen = true
+	}
+}
+
+// Thaw makes a frozen cache writable again
+func (c *Cache) Thaw() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.frozen = false
+	}
+}
+
+// Frozen reports whether the cache is frozen
+func (c *Cache) Frozen() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.frozen
+}
+
+// frozenError returns the result of a write rejected because the cache is frozen
+func (c *Cache) frozenError() error {
+	if c.CacheOpts.IgnoreFrozenWrites {
+		return nil
+	}
+	return ErrFrozen
+}
@@ -0,0 +1,84 @@
+package cache
+
+import "time"
+
+// FullBehavior decides what a Put does when the cache is at capacity
+type FullBehavior int
+
+const (
+	// FullEvict evicts the least recently used items to make room
+	FullEvict FullBehavior = iota
+	// FullReject fails the Put with ErrCacheFull
+	FullReject
+	// FullBlock waits for items to be deleted or to expire, up to
+	// FullTimeout, then fails the Put with ErrCacheFull
+	FullBlock
+)
+
+// awaitRoom applies FullBehavior before a value of the given size is stored
+// under key. When blocking, it unlocks the cache while waiting.
+func (c *Cache) awaitRoom(key string, size int) error {
+	behavior := c.CacheOpts.FullBehavior
+	if behavior == FullEvict {
+		return nil
+	}
+	entries, bytes := c.footprint(size)
+	if !c.fits(entries, bytes) {
+		return nil // The Put fails with ErrValueTooLarge
+	}
+
+	var timeout <-chan time.Time
+	if behavior == FullBlock && c.CacheOpts.FullTimeout > 0 {
+		timer := time.NewTimer(c.CacheOpts.FullTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for {
+		// An overwrite frees the old value's room first
+		freedEntries, freedBytes := 0, int64(0)
+		if _, found := c.items.Get(key); found {
+			freedEntries, freedBytes = 1+len(c.chunks[key]), c.sizeOf(key)
+		}
+		if !c.full(c.namespaceOf(key), entries-freedEntries, bytes-freedBytes) {
+			return nil
+		}
+		if behavior == FullReject {
+			return ErrCacheFull
+		}
+
+		if c.roomFreed == nil {
+			c.roomFreed = make(chan struct{})
+		}
+		freed := c.roomFreed
+		c.mu.Unlock()
+		select {
+		case <-freed:
+			c.mu.Lock()
+		case <-timeout:
+			c.mu.Lock()
+			return ErrCacheFull
+		}
+		if c.closed {
+			return ErrClosed
+		}
+		if c.frozen {
+			return c.frozenError()
+		}
+	}
+}
+
+// freeRoom wakes the Puts waiting for room
+func (c *Cache) freeRoom() {
+	if c.roomFreed != nil {
+		close(c.roomFreed)
+		c.roomFreed = nil
+	}
+}
+
+// footprint returns the entries and bytes a value of the given size takes
+func (c *Cache) footprint(size int) (int, int64) {
+	if chunkSize := c.CacheOpts.ChunkSize; chunkSize > 0 && size > chunkSize {
+		return (size+chunkSize-1)/chunkSize + 1, int64(size + manifestSize)
+	}
+	return 1, int64(size)
+}
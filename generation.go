@@ -0,0 +1,35 @@
+package cache
+
+// BumpGeneration invalidates every entry of a namespace in O(1). Entries
+// remember the namespace generation they were written in and read as expired
+// once it moves on; their memory is reclaimed as they are read, evicted or
+// purged, rather than deleted all at once under the lock.
+func (c *Cache) BumpGeneration(namespace string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.nsGens[namespace]++
+	}
+}
+
+// Generation returns the current generation of a namespace
+func (c *Cache) Generation(namespace string) uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.nsGens[namespace]
+}
+
+// setGeneration stamps an entry with its namespace's current generation
+func (c *Cache) setGeneration(key string) {
+	if gen := c.nsGens[c.namespaceOf(key)]; gen != 0 {
+		c.generations[key] = gen
+	} else {
+		delete(c.generations, key)
+	}
+}
+
+// outdated reports whether an entry was written before its namespace's
+// generation was last bumped
+func (c *Cache) outdated(key string) bool {
+	return c.generations[key] != c.nsGens[c.namespaceOf(key)]
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// GetRange retrieves up to length bytes of an item's value starting at
+// offset, and updates its usage like Get. A negative length reads to the end
+// of the value. size is the length of the whole value, e.g. for a
+// Content-Range header. Ranges within a single stored slice are returned
+// without copying and must not be modified.
+func (c *Cache) GetRange(key []byte, offset, length int64) (part []byte, size int64, err error) {
+	if c.CacheOpts.Audit != nil {
+		defer func(start time.Time) { c.audit(context.Background(), AuditGet, key, len(part), start, "hit", err) }(time.Now())
+	}
+	if offset < 0 {
+		return nil, 0, ErrNegativeOffset
+	}
+	if err := c.limit(context.Background(), key); err != nil {
+		return nil, 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, 0, ErrClosed
+	}
+
+	strKey, err := c.lookup(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	parts := [][]byte{c.stored(strKey)}
+	if c.transformerOf(strKey) != nil {
+		value, err := c.read(strKey)
+		if err != nil {
+			return nil, 0, err
+		}
+		parts[0] = value
+	} else if chunkKeys, ok := c.chunks[strKey]; ok {
+		parts = make([][]byte, len(chunkKeys))
+		for i, chunkKey := range chunkKeys {
+			parts[i] = c.stored(chunkKey)
+		}
+	}
+	for _, p := range parts {
+		size += int64(len(p))
+	}
+	end := size
+	if length >= 0 {
+		end = min(offset+length, size)
+	}
+	if offset >= end {
+		return []byte{}, size, nil
+	}
+
+	// Skip to the part holding offset and slice it if the range fits
+	for len(parts) > 0 && offset >= int64(len(parts[0])) {
+		offset -= int64(len(parts[0]))
+		end -= int64(len(parts[0]))
+		parts = parts[1:]
+	}
+	if end <= int64(len(parts[0])) {
+		return parts[0][offset:end:end], size, nil
+	}
+	want := end - offset
+	part = make([]byte, 0, want)
+	for _, p := range parts {
+		n := min(int64(len(p))-offset, want-int64(len(part)))
+		part = append(part, p[offset:offset+n]...)
+		if int64(len(part)) == want {
+			break
+		}
+		offset = 0
+	}
+	return part, size, nil
+}
@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGetRange(t *testing.T) {
+	const value = "0123456789"
+	tests := []struct {
+		offset, length int64
+		want           string
+		wantErr        error
+	}{
+		{0, -1, value, nil},
+		{0, 3, "012", nil},
+		{3, 4, "3456", nil}, // Spans chunks
+		{8, 10, "89", nil},
+		{10, 1, "", nil},
+		{20, -1, "", nil},
+		{-1, 1, "", ErrNegativeOffset},
+	}
+	for _, chunkSize := range []int{0, 4} {
+		c := NewCache(CacheOpts{Capacity: 100, ChunkSize: chunkSize})
+		c.Put([]byte("k"), []byte(value))
+		for _, tt := range tests {
+			part, size, err := c.GetRange([]byte("k"), tt.offset, tt.length)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("chunk size %d: GetRange(%d, %d) error %v, want %v", chunkSize, tt.offset, tt.length, err, tt.wantErr)
+				continue
+			}
+			if err == nil && (string(part) != tt.want || size != int64(len(value))) {
+				t.Errorf("chunk size %d: GetRange(%d, %d) = %q, %d; want %q, %d", chunkSize, tt.offset, tt.length, part, size, tt.want, len(value))
+			}
+		}
+		if _, _, err := c.GetRange([]byte("missing"), 0, 1); !isMiss(err) {
+			t.Errorf("chunk size %d: GetRange of a missing key = %v, want a miss", chunkSize, err)
+		}
+		c.Close(context.Background())
+	}
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Group is a byte budget shared by several caches. When the caches together
+// store more than the budget, entries are evicted from the member using the
+// most bytes relative to its GroupWeight, so every member converges to its
+// proportional share under pressure while idle members' memory stays
+// available to busy ones. Each member keeps its own options and Capacity.
+type Group struct {
+	maxBytes int64
+	used     atomic.Int64 // Bytes stored by all members
+
+	mu      sync.Mutex // Guards members and serializes reclaiming
+	members map[*Cache]*groupMember
+}
+
+// groupMember is the accounting of one cache in a group
+type groupMember struct {
+	weight float64
+	bytes  atomic.Int64
+}
+
+// NewGroup creates a group sharing maxBytes between its caches. Caches join
+// it through CacheOpts.Group.
+func NewGroup(maxBytes int64) *Group {
+	return &Group{maxBytes: maxBytes, members: make(map[*Cache]*groupMember)}
+}
+
+// Bytes returns the number of bytes stored by all caches of the group
+func (g *Group) Bytes() int64 {
+	return g.used.Load()
+}
+
+// join adds a cache to the group
+func (g *Group) join(c *Cache) *groupMember {
+	m := &groupMember{weight: c.CacheOpts.GroupWeight}
+	if m.weight <= 0 {
+		m.weight = 1
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.members[c] = m
+	return m
+}
+
+// leave removes a cache and its bytes from the group
+func (g *Group) leave(c *Cache) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if m, ok := g.members[c]; ok {
+		g.used.Add(-m.bytes.Load())
+		delete(g.members, c)
+	}
+}
+
+// add accounts for a change in the bytes stored by a member
+func (g *Group) add(m *groupMember, delta int64) {
+	m.bytes.Add(delta)
+	g.used.Add(delta)
+}
+
+// reclaim evicts entries from the members furthest above their share until
+// the group fits its budget. It is called after a write with no cache locked.
+func (g *Group) reclaim() {
+	if g.used.Load() <= g.maxBytes {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for g.used.Load() > g.maxBytes {
+		var victim *Cache
+		var worst float64
+		for c, m := range g.members {
+			if share := float64(m.bytes.Load()) / m.weight; share > worst {
+				victim, worst = c, share
+			}
+		}
+		if victim == nil || !victim.evictForGroup() {
+			return
+		}
+	}
+}
+
+// reclaimGroup brings the cache's group back within its budget after a
+// write. The cache must not be locked.
+func (c *Cache) reclaimGroup() {
+	if c.member != nil {
+		c.CacheOpts.Group.reclaim()
+	}
+}
+
+// evictForGroup evicts one entry to make room in the cache's group and
+// reports whether anything was evicted
+func (c *Cache) evictForGroup() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || c.items.Len() == 0 {
+		return false
+	}
+	c.evict("group")
+	return true
+}
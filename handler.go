@@ -0,0 +1,262 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CachingHandler is net/http middleware that caches full responses
+// (status, headers and body) in a Cache
+type CachingHandler struct {
+	cache *Cache
+	next  http.Handler
+	keyFn func(r *http.Request) string
+	ttlFn func(r *http.Request, status int, header http.Header) time.Duration
+
+	// InvalidateFn, if set, is called for requests with unsafe methods
+	// (POST, PUT, DELETE, ...) and returns the cache keys to invalidate
+	// once the wrapped handler has responded successfully
+	InvalidateFn func(r *http.Request) []string
+}
+
+// cachedVariant is a single stored response for one combination of Vary headers
+type cachedVariant struct {
+	VaryKey string
+	Expires time.Time
+	Status  int
+	Header  http.Header
+	Body    []byte
+}
+
+// cachedResource holds every stored variant of the response for a key
+type cachedResource struct {
+	Vary     []string
+	Variants []cachedVariant
+}
+
+// Handler wraps next with a response cache. keyFn derives the cache key from
+// the request and defaults to the URL, so HEAD requests are served from
+// cached GET responses. ttlFn decides how long a response is cached; a
+// non-positive TTL skips caching. If ttlFn is nil only 200 responses are
+// cached, bounded by the cache's own TTL.
+func (c *Cache) Handler(next http.Handler, keyFn func(r *http.Request) string, ttlFn func(r *http.Request, status int, header http.Header) time.Duration) *CachingHandler {
+	if keyFn == nil {
+		keyFn = func(r *http.Request) string {
+			return r.URL.String()
+		}
+	}
+	return &CachingHandler{cache: c, next: next, keyFn: keyFn, ttlFn: ttlFn}
+}
+
+// Invalidate removes all cached variants stored under key
+func (h *CachingHandler) Invalidate(key string) {
+	h.cache.Delete([]byte(key))
+}
+
+// ServeHTTP implements http.Handler
+func (h *CachingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		h.serveUnsafe(w, r)
+		return
+	}
+	if _, ok := parseCacheControl(r.Header)["no-store"]; ok {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	key := h.keyFn(r)
+	res := h.load(key)
+	if res != nil {
+		varyKey := varyKey(res.Vary, r)
+		for _, v := range res.Variants {
+			if v.VaryKey == varyKey && (v.Expires.IsZero() || time.Now().Before(v.Expires)) {
+				writeCached(w, r, v)
+				return
+			}
+		}
+	}
+
+	rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+	h.next.ServeHTTP(rec, r)
+	h.save(key, res, r, rec)
+}
+
+// serveUnsafe passes the request through and runs the invalidation hook
+func (h *CachingHandler) serveUnsafe(w http.ResponseWriter, r *http.Request) {
+	if h.InvalidateFn == nil {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK, discard: true}
+	h.next.ServeHTTP(rec, r)
+	if rec.status < 400 {
+		for _, key := range h.InvalidateFn(r) {
+			h.Invalidate(key)
+		}
+	}
+}
+
+// load decodes the cached resource stored under key, returning nil on a
+// cache miss or an undecodable entry
+func (h *CachingHandler) load(key string) *cachedResource {
+	data, err := h.cache.Get([]byte(key))
+	if err != nil {
+		return nil
+	}
+	res := &cachedResource{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(res); err != nil {
+		h.cache.reportError(fmt.Errorf("handler: decoding entry: %w", err))
+		return nil
+	}
+	return res
+}
+
+// save stores the recorded response as a variant of the resource under key
+func (h *CachingHandler) save(key string, res *cachedResource, r *http.Request, rec *responseRecorder) {
+	if r.Method != http.MethodGet {
+		return
+	}
+	if !rec.wroteHeader {
+		rec.header = rec.Header().Clone()
+	}
+	var expires time.Time
+	if h.ttlFn != nil {
+		ttl := h.ttlFn(r, rec.status, rec.header)
+		if ttl <= 0 {
+			return
+		}
+		expires = time.Now().Add(ttl)
+	} else if rec.status != http.StatusOK {
+		return
+	}
+	if _, ok := parseCacheControl(rec.header)["no-store"]; ok {
+		return
+	}
+
+	vary := parseVary(rec.header)
+	if len(vary) == 1 && vary[0] == "*" {
+		return
+	}
+	if res == nil || !equalStrings(res.Vary, vary) {
+		res = &cachedResource{Vary: vary}
+	}
+	variant := cachedVariant{
+		VaryKey: varyKey(vary, r),
+		Expires: expires,
+		Status:  rec.status,
+		Header:  rec.header,
+		Body:    rec.body.Bytes(),
+	}
+	variants := []cachedVariant{variant}
+	for _, v := range res.Variants {
+		if v.VaryKey != variant.VaryKey && (v.Expires.IsZero() || time.Now().Before(v.Expires)) {
+			variants = append(variants, v)
+		}
+	}
+	res.Variants = variants
+
+	// The entry lives as long as its longest-lived variant
+	var ttl time.Duration
+	if h.ttlFn != nil {
+		for _, v := range variants {
+			ttl = max(ttl, time.Until(v.Expires))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(res); err != nil {
+		h.cache.reportError(fmt.Errorf("handler: encoding entry: %w", err))
+		return
+	}
+	if err := h.cache.PutWithTTL([]byte(key), buf.Bytes(), ttl); err != nil {
+		h.cache.reportError(fmt.Errorf("handler: storing response: %w", err))
+	}
+}
+
+// writeCached replays a cached variant to the client
+func writeCached(w http.ResponseWriter, r *http.Request, v cachedVariant) {
+	for name, values := range v.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(XFromCache, "1")
+	w.WriteHeader(v.Status)
+	if r.Method != http.MethodHead {
+		w.Write(v.Body)
+	}
+}
+
+// parseVary returns the canonical, sorted header names listed in Vary
+func parseVary(h http.Header) []string {
+	var names []string
+	for _, value := range h.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// varyKey builds the variant key from the request values of the Vary headers
+func varyKey(vary []string, r *http.Request) string {
+	var b strings.Builder
+	for _, name := range vary {
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(strings.Join(r.Header.Values(name), ","))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// equalStrings reports whether two string slices are identical
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// responseRecorder passes a response through to the client while capturing it
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	header      http.Header
+	body        bytes.Buffer
+	wroteHeader bool
+	discard     bool // Only track the status, not the body
+}
+
+// WriteHeader records the status and a copy of the headers
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.wroteHeader {
+		return
+	}
+	rec.wroteHeader = true
+	rec.status = status
+	rec.header = rec.Header().Clone()
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the body while writing it to the client
+func (rec *responseRecorder) Write(p []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if !rec.discard {
+		rec.body.Write(p)
+	}
+	return rec.ResponseWriter.Write(p)
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	type request struct {
+		method string
+		header http.Header
+	}
+	get := request{method: http.MethodGet}
+	tests := []struct {
+		name     string
+		requests []request
+		status   int
+		header   http.Header // Response headers set by the wrapped handler
+		ttl      time.Duration
+		calls    int  // Calls to the wrapped handler
+		cached   bool // Last response served from the cache
+	}{
+		{name: "get", requests: []request{get, get}, calls: 1, cached: true},
+		{name: "head after get", requests: []request{get, {method: http.MethodHead}}, calls: 1, cached: true},
+		{name: "get after head", requests: []request{{method: http.MethodHead}, get}, calls: 2},
+		{name: "no-store request", requests: []request{get, {method: http.MethodGet, header: http.Header{"Cache-Control": {"no-store"}}}}, calls: 2},
+		{name: "no-store response", requests: []request{get, get}, header: http.Header{"Cache-Control": {"no-store"}}, calls: 2},
+		{name: "error without ttl", requests: []request{get, get}, status: http.StatusNotFound, calls: 2},
+		{name: "error with ttl", requests: []request{get, get}, status: http.StatusNotFound, ttl: time.Minute, calls: 1, cached: true},
+		{name: "expired", requests: []request{get, get}, ttl: time.Nanosecond, calls: 2},
+		{
+			name:     "same variant",
+			requests: []request{{http.MethodGet, http.Header{"Accept-Language": {"en"}}}, {http.MethodGet, http.Header{"Accept-Language": {"en"}}}},
+			header:   http.Header{"Vary": {"Accept-Language"}},
+			calls:    1,
+			cached:   true,
+		},
+		{
+			name:     "other variant",
+			requests: []request{{http.MethodGet, http.Header{"Accept-Language": {"en"}}}, {http.MethodGet, http.Header{"Accept-Language": {"fr"}}}},
+			header:   http.Header{"Vary": {"Accept-Language"}},
+			calls:    2,
+		},
+		{name: "vary star", requests: []request{get, get}, header: http.Header{"Vary": {"*"}}, calls: 2},
+		{name: "invalidated", requests: []request{get, {method: http.MethodPost}, get}, calls: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(CacheOpts{Capacity: 10})
+			defer c.Close(context.Background())
+			calls := 0
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				for name, values := range tt.header {
+					w.Header()[name] = values
+				}
+				w.WriteHeader(max(tt.status, http.StatusOK))
+				w.Write([]byte("body"))
+			})
+			var ttlFn func(*http.Request, int, http.Header) time.Duration
+			if tt.ttl != 0 {
+				ttlFn = func(*http.Request, int, http.Header) time.Duration { return tt.ttl }
+			}
+			h := c.Handler(next, nil, ttlFn)
+			h.InvalidateFn = func(r *http.Request) []string { return []string{r.URL.String()} }
+
+			var rec *httptest.ResponseRecorder
+			for _, req := range tt.requests {
+				r := httptest.NewRequest(req.method, "http://example.com/a", nil)
+				for name, values := range req.header {
+					r.Header[name] = values
+				}
+				rec = httptest.NewRecorder()
+				h.ServeHTTP(rec, r)
+			}
+
+			if calls != tt.calls {
+				t.Errorf("handler calls = %d, want %d", calls, tt.calls)
+			}
+			if got := rec.Header().Get(XFromCache) != ""; got != tt.cached {
+				t.Errorf("served from cache = %v, want %v", got, tt.cached)
+			}
+			if want := max(tt.status, http.StatusOK); rec.Code != want {
+				t.Errorf("status = %d, want %d", rec.Code, want)
+			}
+			wantBody := "body"
+			if tt.requests[len(tt.requests)-1].method == http.MethodHead && tt.cached {
+				wantBody = ""
+			}
+			if got := rec.Body.String(); got != wantBody {
+				t.Errorf("body = %q, want %q", got, wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// stallIntervals is how many intervals a background worker may miss before
+// the cache is reported unhealthy
+const stallIntervals = 3
+
+// Health reports whether the cache is usable: it is not closed and its
+// expiry and trimming workers are running
+func (c *Cache) Health() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.health()
+}
+
+// health checks the cache's liveness, with the cache locked
+func (c *Cache) health() error {
+	if c.closed {
+		return ErrClosed
+	}
+	now := time.Now()
+	if c.wheel != nil && stalled(c.lastExpiry, c.created, c.CacheOpts.ExpiryTick, now) {
+		return fmt.Errorf("%w: expiry last ran at %s", ErrStalled, c.lastExpiry.Format(time.RFC3339))
+	}
+	if c.CacheOpts.LowWatermark > 0 {
+		interval := c.CacheOpts.TrimInterval
+		if interval <= 0 {
+			interval = time.Second
+		}
+		if stalled(c.lastTrim, c.created, interval, now) {
+			return fmt.Errorf("%w: trimming last ran at %s", ErrStalled, c.lastTrim.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// stalled reports whether a worker started with the cache missed too many
+// of its runs
+func stalled(last, created time.Time, interval time.Duration, now time.Time) bool {
+	if last.IsZero() {
+		last = created
+	}
+	return now.Sub(last) > stallIntervals*interval
+}
+
+// Ready reports whether the cache should receive traffic: it is healthy and
+// not under more pressure than ReadyMaxEvictionRate and ReadyMaxUsage allow
+func (c *Cache) Ready() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.health(); err != nil {
+		return err
+	}
+	p := c.pressure()
+	if limit := c.CacheOpts.ReadyMaxEvictionRate; limit > 0 && p.EvictionRate > limit {
+		return fmt.Errorf("%w: %.1f evictions per second", ErrUnderPressure, p.EvictionRate)
+	}
+	if limit := c.CacheOpts.ReadyMaxUsage; limit > 0 && p.Usage > limit {
+		return fmt.Errorf("%w: %.0f%% used", ErrUnderPressure, p.Usage*100)
+	}
+	return nil
+}
+
+// HealthHandler serves liveness and readiness probes for orchestrators.
+// Requests to paths ending in /readyz check Ready, any other path checks
+// Health. It responds 200 with "ok", or 503 with the error.
+func (c *Cache) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		check := c.Health
+		if strings.HasSuffix(r.URL.Path, "/readyz") {
+			check = c.Ready
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := check(); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, err)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+}
@@ -0,0 +1,201 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XFromCache is set on responses that were served from the cache
+const XFromCache = "X-From-Cache"
+
+// Transport is an http.RoundTripper that serves GET responses from a Cache.
+// It honors Cache-Control, Expires, ETag and Last-Modified, and revalidates
+// stale entries with conditional requests before falling back to the origin.
+type Transport struct {
+	Cache     *Cache
+	Transport http.RoundTripper // Underlying transport, http.DefaultTransport if nil
+}
+
+// NewTransport creates a new Transport backed by the given cache
+func NewTransport(c *Cache) *Transport {
+	return &Transport{Cache: c}
+}
+
+// Client returns an *http.Client that uses the Transport
+func (t *Transport) Client() *http.Client {
+	return &http.Client{Transport: t}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := []byte(req.Method + " " + req.URL.String())
+	cacheable := req.Method == http.MethodGet && req.Header.Get("Range") == ""
+	reqCC := parseCacheControl(req.Header)
+	if _, ok := reqCC["no-store"]; ok {
+		cacheable = false
+	}
+	if !cacheable {
+		return t.transport().RoundTrip(req)
+	}
+
+	cached, stored := t.load(key, req)
+	if cached == nil {
+		return t.fetch(key, req)
+	}
+
+	_, noCache := reqCC["no-cache"]
+	if !noCache && isFresh(cached.Header, stored) {
+		cached.Header.Set(XFromCache, "1")
+		return cached, nil
+	}
+
+	// Revalidate the stale entry with a conditional request
+	etag, lastModified := cached.Header.Get("ETag"), cached.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		cached.Body.Close()
+		return t.fetch(key, req)
+	}
+	condReq := req.Clone(req.Context())
+	if etag != "" {
+		condReq.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		condReq.Header.Set("If-Modified-Since", lastModified)
+	}
+	resp, err := t.transport().RoundTrip(condReq)
+	if err != nil {
+		cached.Body.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusNotModified {
+		cached.Body.Close()
+		return t.store(key, resp)
+	}
+	resp.Body.Close()
+
+	// Merge the updated headers from the 304 into the cached response
+	for name, values := range resp.Header {
+		cached.Header[name] = values
+	}
+	cached, err = t.store(key, cached)
+	if err != nil {
+		return nil, err
+	}
+	cached.Header.Set(XFromCache, "1")
+	return cached, nil
+}
+
+// transport returns the underlying round tripper
+func (t *Transport) transport() http.RoundTripper {
+	if t.Transport != nil {
+		return t.Transport
+	}
+	return http.DefaultTransport
+}
+
+// fetch performs the request against the origin and caches the response
+func (t *Transport) fetch(key []byte, req *http.Request) (*http.Response, error) {
+	resp, err := t.transport().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	return t.store(key, resp)
+}
+
+// load decodes a cached response and the time it was stored. It returns a
+// nil response on a cache miss or an undecodable entry.
+func (t *Transport) load(key []byte, req *http.Request) (*http.Response, time.Time) {
+	data, err := t.Cache.Get(key)
+	if err != nil {
+		return nil, time.Time{}
+	}
+	if len(data) < 8 {
+		t.Cache.reportError(errors.New("httpcache: malformed entry"))
+		return nil, time.Time{}
+	}
+	stored := time.Unix(0, int64(binary.BigEndian.Uint64(data[:8])))
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data[8:])), req)
+	if err != nil {
+		t.Cache.reportError(fmt.Errorf("httpcache: decoding entry: %w", err))
+		return nil, time.Time{}
+	}
+	return resp, stored
+}
+
+// store caches the response if it is cacheable and returns it with a readable body
+func (t *Transport) store(key []byte, resp *http.Response) (*http.Response, error) {
+	if resp.StatusCode != http.StatusOK || !isStorable(resp.Header) {
+		return resp, nil
+	}
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	data := make([]byte, 8, 8+len(dump))
+	binary.BigEndian.PutUint64(data, uint64(time.Now().UnixNano()))
+	data = append(data, dump...)
+	// The response is still usable if caching fails
+	if err := t.Cache.Put(key, data); err != nil {
+		t.Cache.reportError(fmt.Errorf("httpcache: storing response: %w", err))
+	}
+	return resp, nil
+}
+
+// isStorable reports whether a response may be cached at all
+func isStorable(h http.Header) bool {
+	cc := parseCacheControl(h)
+	if _, ok := cc["no-store"]; ok {
+		return false
+	}
+	if _, ok := cc["max-age"]; ok {
+		return true
+	}
+	return h.Get("Expires") != "" || h.Get("ETag") != "" || h.Get("Last-Modified") != ""
+}
+
+// isFresh reports whether a response stored at the given time can be served without revalidation
+func isFresh(h http.Header, stored time.Time) bool {
+	cc := parseCacheControl(h)
+	if _, ok := cc["no-cache"]; ok {
+		return false
+	}
+	age := time.Since(stored)
+	if maxAge, ok := cc["max-age"]; ok {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil {
+			return false
+		}
+		return age < time.Duration(seconds)*time.Second
+	}
+	if expires := h.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return false
+		}
+		return time.Now().Before(t)
+	}
+	return false
+}
+
+// parseCacheControl parses the Cache-Control header into its directives
+func parseCacheControl(h http.Header) map[string]string {
+	cc := map[string]string{}
+	for _, part := range strings.Split(h.Get("Cache-Control"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		cc[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return cc
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// roundTripFunc is an http.RoundTripper standing in for the origin
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		reqHeader http.Header
+		header    http.Header // Origin response headers
+		calls     int         // Origin calls for two identical requests
+		cached    bool        // Second response served from the cache
+	}{
+		{name: "fresh", header: http.Header{"Cache-Control": {"max-age=60"}}, calls: 1, cached: true},
+		{name: "no-store response", header: http.Header{"Cache-Control": {"no-store, max-age=60"}}, calls: 2},
+		{name: "no-store request", reqHeader: http.Header{"Cache-Control": {"no-store"}}, header: http.Header{"Cache-Control": {"max-age=60"}}, calls: 2},
+		{name: "uncacheable", header: http.Header{}, calls: 2},
+		{name: "revalidated", header: http.Header{"Cache-Control": {"no-cache"}, "Etag": {`"v1"`}}, calls: 2, cached: true},
+		{name: "no-cache request", reqHeader: http.Header{"Cache-Control": {"no-cache"}}, header: http.Header{"Cache-Control": {"max-age=60"}}, calls: 2},
+		{name: "post", method: http.MethodPost, header: http.Header{"Cache-Control": {"max-age=60"}}, calls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(CacheOpts{Capacity: 10})
+			defer c.Close(context.Background())
+			calls := 0
+			origin := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				calls++
+				status := http.StatusOK
+				if etag := tt.header.Get("ETag"); etag != "" && req.Header.Get("If-None-Match") == etag {
+					status = http.StatusNotModified
+				}
+				return &http.Response{
+					StatusCode: status,
+					Header:     tt.header.Clone(),
+					Body:       io.NopCloser(strings.NewReader("body")),
+					Request:    req,
+				}, nil
+			})
+			client := (&Transport{Cache: c, Transport: origin}).Client()
+
+			var resp *http.Response
+			for range 2 {
+				method := tt.method
+				if method == "" {
+					method = http.MethodGet
+				}
+				req, err := http.NewRequest(method, "http://example.com/a", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for name, values := range tt.reqHeader {
+					req.Header[name] = values
+				}
+				resp, err = client.Do(req)
+				if err != nil {
+					t.Fatalf("Do = %v", err)
+				}
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if string(body) != "body" {
+					t.Fatalf("body = %q, want %q", body, "body")
+				}
+			}
+
+			if calls != tt.calls {
+				t.Errorf("origin calls = %d, want %d", calls, tt.calls)
+			}
+			if got := resp.Header.Get(XFromCache) != ""; got != tt.cached {
+				t.Errorf("served from cache = %v, want %v", got, tt.cached)
+			}
+		})
+	}
+}
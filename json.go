@@ -0,0 +1,24 @@
+package cache
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PutJSON stores v encoded as JSON with the given TTL, 0 uses the cache TTL
+func (c *Cache) PutJSON(key []byte, v any, ttl time.Duration) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.PutWithTTL(key, value, ttl)
+}
+
+// GetJSON retrieves an item and decodes it from JSON into dst
+func (c *Cache) GetJSON(key []byte, dst any) error {
+	value, err := c.Get(key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(value, dst)
+}
@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// KeyCodec encodes keys of type K into cache keys. Different keys must
+// encode differently, and equal keys the same way.
+type KeyCodec[K any] interface {
+	EncodeKey(key K) ([]byte, error)
+}
+
+// KeyCodecFunc adapts a function to a KeyCodec
+type KeyCodecFunc[K any] func(key K) ([]byte, error)
+
+// EncodeKey calls f
+func (f KeyCodecFunc[K]) EncodeKey(key K) ([]byte, error) {
+	return f(key)
+}
+
+// DefaultKeyCodec encodes strings and byte slices as they are, integers in
+// decimal, and other keys with MarshalBinary, MarshalText or String, in that
+// order of preference, so e.g. UUIDs need no conversion. Named types such as
+// type UserID int without any of those methods are encoded by their
+// underlying type. Other keys fail with ErrUnsupportedKey.
+func DefaultKeyCodec[K any]() KeyCodec[K] {
+	return KeyCodecFunc[K](func(key K) ([]byte, error) {
+		return encodeKey(key)
+	})
+}
+
+// encodeKey encodes a key of any supported type
+func encodeKey(key any) ([]byte, error) {
+	switch k := key.(type) {
+	case []byte:
+		return k, nil
+	case string:
+		return []byte(k), nil
+	case encoding.BinaryMarshaler:
+		return k.MarshalBinary()
+	case encoding.TextMarshaler:
+		return k.MarshalText()
+	case fmt.Stringer:
+		return []byte(k.String()), nil
+	}
+
+	v := reflect.ValueOf(key)
+	switch v.Kind() {
+	case reflect.String:
+		return []byte(v.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.AppendInt(nil, v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.AppendUint(nil, v.Uint(), 10), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Bytes(), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %T", ErrUnsupportedKey, key)
+}
+
+// KeyedCache is a view of a cache taking keys of type K, encoded with a
+// KeyCodec, e.g. KeyedCache[uuid.UUID] for a cache of users by ID. Keys of
+// different types encoding to the same bytes share an entry, so give each
+// KeyedCache of a cache its own namespace with WithPrefix.
+type KeyedCache[K any] struct {
+	cache  *Cache
+	codec  KeyCodec[K]
+	prefix []byte
+}
+
+// NewKeyedCache creates a view of c taking keys of type K, encoded with
+// codec or DefaultKeyCodec if it is nil
+func NewKeyedCache[K any](c *Cache, codec KeyCodec[K]) *KeyedCache[K] {
+	if codec == nil {
+		codec = DefaultKeyCodec[K]()
+	}
+	return &KeyedCache[K]{cache: c, codec: codec}
+}
+
+// WithPrefix returns a view prepending prefix to every encoded key, e.g. a
+// namespace and its separator
+func (k *KeyedCache[K]) WithPrefix(prefix string) *KeyedCache[K] {
+	return &KeyedCache[K]{cache: k.cache, codec: k.codec, prefix: []byte(prefix)}
+}
+
+// Key returns the cache key of key
+func (k *KeyedCache[K]) Key(key K) ([]byte, error) {
+	encoded, err := k.codec.EncodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(k.prefix) == 0 {
+		return encoded, nil
+	}
+	return append(append(make([]byte, 0, len(k.prefix)+len(encoded)), k.prefix...), encoded...), nil
+}
+
+// Get retrieves an item
+func (k *KeyedCache[K]) Get(key K) ([]byte, error) {
+	b, err := k.Key(key)
+	if err != nil {
+		return nil, err
+	}
+	return k.cache.Get(b)
+}
+
+// Put stores an item with the given TTL, 0 uses the cache TTL
+func (k *KeyedCache[K]) Put(key K, value []byte, ttl time.Duration) error {
+	b, err := k.Key(key)
+	if err != nil {
+		return err
+	}
+	return k.cache.PutWithTTL(b, value, ttl)
+}
+
+// Has checks if a key exists; keys that fail to encode do not
+func (k *KeyedCache[K]) Has(key K) bool {
+	b, err := k.Key(key)
+	return err == nil && k.cache.Has(b)
+}
+
+// Delete removes an item, reporting whether it was stored
+func (k *KeyedCache[K]) Delete(key K) bool {
+	b, err := k.Key(key)
+	return err == nil && k.cache.Delete(b)
+}
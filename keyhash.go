@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash/maphash"
+)
+
+// keyedDigestSize is the length of HMAC digests of keys under KeySecret
+const keyedDigestSize = 16
+
+// CollisionPolicy decides how a Put handles a key whose digest is already
+// stored for a different key. Collisions are detected with a second,
+// independently seeded fingerprint of the key; Get and Has never return the
+// value of a colliding key.
+type CollisionPolicy int
+
+const (
+	// CollisionOverwrite replaces the entry of the colliding key
+	CollisionOverwrite CollisionPolicy = iota
+	// CollisionReject keeps the existing entry and fails the Put with ErrKeyCollision
+	CollisionReject
+)
+
+// storageKey returns the key an item is stored under
+func (c *Cache) storageKey(key []byte) string {
+	if !c.CacheOpts.HashKeys {
+		return string(key)
+	}
+	digest := make([]byte, 0, keyedDigestSize)
+	if sep := c.CacheOpts.NamespaceSeparator; sep != "" {
+		if i := bytes.Index(key, []byte(sep)); i >= 0 {
+			digest = append(digest, key[:i+len(sep)]...)
+		}
+	}
+	if c.CacheOpts.KeySecret != nil {
+		mac := hmac.New(sha256.New, c.CacheOpts.KeySecret)
+		mac.Write(key)
+		return string(mac.Sum(digest)[:len(digest)+keyedDigestSize])
+	}
+	digest = binary.BigEndian.AppendUint64(digest, maphash.Bytes(c.keySeed, key))
+	return string(digest)
+}
+
+// fingerprint returns the collision-detection fingerprint of a key
+func (c *Cache) fingerprint(key []byte) uint64 {
+	return maphash.Bytes(c.fpSeed, key)
+}
+
+// collides reports whether the item stored under strKey belongs to a different key
+func (c *Cache) collides(strKey string, key []byte) bool {
+	if !c.CacheOpts.HashKeys {
+		return false
+	}
+	fp, ok := c.fingerprints[strKey]
+	return ok && fp != c.fingerprint(key)
+}
+
+// checkCollision applies the collision policy before key is written under strKey
+func (c *Cache) checkCollision(strKey string, key []byte) error {
+	if c.CacheOpts.CollisionPolicy == CollisionReject && c.collides(strKey, key) {
+		return ErrKeyCollision
+	}
+	return nil
+}
+
+// setFingerprint records the fingerprint of the key stored under strKey
+func (c *Cache) setFingerprint(strKey string, key []byte) {
+	if c.CacheOpts.HashKeys {
+		c.fingerprints[strKey] = c.fingerprint(key)
+	}
+}
@@ -0,0 +1,75 @@
+package cache
+
+// KeyMetrics are the read counters of a single key
+type KeyMetrics struct {
+	Hits   int
+	Misses int
+}
+
+// keyCounter counts the reads of a key tracked for KeyMetrics
+type keyCounter struct {
+	KeyMetrics
+	reads  int  // Reads ranking the key among the top keys, overestimated by those of the key it replaced
+	listed bool // Listed in MetricKeys, so never replaced
+}
+
+// trackKeys sets up the counters of the keys listed in MetricKeys
+func (c *Cache) trackKeys() {
+	if len(c.CacheOpts.MetricKeys) == 0 && c.CacheOpts.TopMetricKeys <= 0 {
+		return
+	}
+	c.keyCounters = make(map[string]*keyCounter, len(c.CacheOpts.MetricKeys)+max(c.CacheOpts.TopMetricKeys, 0))
+	for _, key := range c.CacheOpts.MetricKeys {
+		c.keyCounters[c.storageKey(key)] = &keyCounter{listed: true}
+	}
+}
+
+// countKey records a read of a key if it is listed or among the top keys.
+// The top keys are kept with the space-saving algorithm: a key that is not
+// tracked replaces the least read top key once TopMetricKeys are tracked.
+func (c *Cache) countKey(key string, hit bool) {
+	if c.keyCounters == nil {
+		return
+	}
+	k, ok := c.keyCounters[key]
+	if !ok {
+		if c.CacheOpts.TopMetricKeys <= 0 {
+			return
+		}
+		k = &keyCounter{}
+		if c.topKeys < c.CacheOpts.TopMetricKeys {
+			c.topKeys++
+		} else {
+			var victim string
+			var least *keyCounter
+			for key, k := range c.keyCounters {
+				if !k.listed && (least == nil || k.reads < least.reads) {
+					victim, least = key, k
+				}
+			}
+			delete(c.keyCounters, victim)
+			k.reads = least.reads
+		}
+		c.keyCounters[key] = k
+	}
+	k.reads++
+	if hit {
+		k.Hits++
+	} else {
+		k.Misses++
+	}
+}
+
+// KeyMetrics returns the read counters of the keys listed in MetricKeys and
+// of the top keys, by their display keys. Counters of top keys start when
+// they became one.
+func (c *Cache) KeyMetrics() map[string]KeyMetrics {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	metrics := make(map[string]KeyMetrics, len(c.keyCounters))
+	for key, k := range c.keyCounters {
+		metrics[c.displayKey([]byte(key))] = k.KeyMetrics
+	}
+	return metrics
+}
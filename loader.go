@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/dhyanio/discache/util"
+)
+
+// loadCall is an in-flight load shared by every caller missing the same key
+type loadCall struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+// loadError is a loader error remembered for LoadErrorTTL
+type loadError struct {
+	err     error
+	expires time.Time
+}
+
+// GetOrLoad retrieves an item from the cache, calling load to fill it on a
+// miss. Concurrent misses for the same key share a single load, which runs
+// on a context detached from theirs, keeping its values but not its
+// cancellation, so a caller giving up does not fail the others; each caller
+// stops waiting once its own ctx is done. Loads are bounded by
+// MaxConcurrentLoads and the namespace's MaxConcurrentLoads; callers over
+// the limit queue until a slot frees up or ctx is done. With
+// LoadErrorTTL set, a failed load's error is returned to the key's callers
+// for that long instead of calling load again. With LoadTimeout set, a stale
+// value stands in for a load taking longer.
+func (c *Cache) GetOrLoad(ctx context.Context, key []byte, load func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	return c.GetOrLoadTTL(ctx, key, func(ctx context.Context) ([]byte, time.Duration, error) {
+		value, err := load(ctx)
+		return value, 0, err
+	})
+}
+
+// GetOrLoadTTL is GetOrLoad with a loader that also returns how long its
+// value stays fresh, e.g. from the upstream response's Cache-Control max-age.
+// A TTL of 0 uses the cache TTL, and a negative TTL returns the value
+// without caching it.
+func (c *Cache) GetOrLoadTTL(ctx context.Context, key []byte, load func(ctx context.Context) ([]byte, time.Duration, error)) ([]byte, error) {
+	var stale []byte
+	if c.CacheOpts.LoadTimeout > 0 {
+		// Read without expiring the item, keeping its value to fall back on
+		value, _, fresh, err := c.getStale(ctx, key)
+		if fresh || (err != nil && !isMiss(err)) {
+			return value, err
+		}
+		stale = value
+	} else if value, err := c.GetContext(ctx, key); err == nil || !isMiss(err) {
+		return value, err
+	}
+
+	strKey := string(key)
+	c.loadMu.Lock()
+	if failed, ok := c.loadErrors[strKey]; ok {
+		if c.now().Before(failed.expires) {
+			c.loadMu.Unlock()
+			return nil, failed.err
+		}
+		delete(c.loadErrors, strKey)
+	}
+	if call, ok := c.calls[strKey]; ok {
+		c.loadMu.Unlock()
+		return c.await(ctx, call, stale)
+	}
+	call := &loadCall{done: make(chan struct{})}
+	c.calls[strKey] = call
+	c.loadMu.Unlock()
+
+	// The load is shared, so it outlives the caller that started it
+	go c.runLoad(context.WithoutCancel(ctx), key, call, load)
+	return c.await(ctx, call, stale)
+}
+
+// runLoad runs the load of a call and publishes its result to the call's
+// waiters. A panicking loader fails the call with a *PanicError rather than
+// leaving its waiters blocked or, in the background, crashing the process.
+func (c *Cache) runLoad(ctx context.Context, key []byte, call *loadCall, load func(ctx context.Context) ([]byte, time.Duration, error)) {
+	strKey := string(key)
+	defer func() {
+		if r := recover(); r != nil {
+			call.value, call.err = nil, &PanicError{Callback: "GetOrLoad", Value: r, Stack: debug.Stack()}
+		}
+		c.loadMu.Lock()
+		delete(c.calls, strKey)
+		if ttl := c.CacheOpts.LoadErrorTTL; ttl > 0 && call.err != nil {
+			c.loadErrors[strKey] = loadError{err: call.err, expires: c.now().Add(ttl)}
+		}
+		c.loadMu.Unlock()
+		close(call.done)
+	}()
+	call.value, call.err = c.load(ctx, key, load)
+}
+
+// await waits for an in-flight load, up to LoadTimeout if set, returning
+// stale instead of a load that takes longer
+func (c *Cache) await(ctx context.Context, call *loadCall, stale []byte) ([]byte, error) {
+	var timeout <-chan time.Time
+	if d := c.CacheOpts.LoadTimeout; d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timeout:
+		if stale == nil {
+			return nil, ErrLoadTimeout
+		}
+		return stale, nil
+	}
+}
+
+// load runs a loader once a load slot is available and caches its result
+func (c *Cache) load(ctx context.Context, key []byte, load func(ctx context.Context) ([]byte, time.Duration, error)) ([]byte, error) {
+	release, err := c.acquireLoadSlots(ctx, c.namespaceOf(string(key)))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	readAt := c.now()
+	start := time.Now()
+	value, ttl, err := load(ctx)
+	c.recordLoad(c.storageKey(key), time.Since(start))
+	if err != nil {
+		return nil, err
+	}
+	if ttl < 0 {
+		return value, nil
+	}
+	if err := c.write(ctx, key, value, writeOpts{ttl: ttl, readAt: readAt}); err != nil && !errors.Is(err, ErrStaleFill) {
+		c.reportError(fmt.Errorf("storing loaded value: %w", err))
+	}
+	return value, nil
+}
+
+// acquireLoadSlots waits for a slot in the cache-wide and namespace loader
+// limits and returns a function releasing them
+func (c *Cache) acquireLoadSlots(ctx context.Context, ns string) (func(), error) {
+	var sems []chan struct{}
+	c.loadMu.Lock()
+	if c.CacheOpts.MaxConcurrentLoads > 0 {
+		sems = append(sems, c.loadSem)
+	}
+	if limit := c.CacheOpts.Namespaces[ns].MaxConcurrentLoads; limit > 0 {
+		sem, ok := c.nsLoadSems[ns]
+		if !ok {
+			sem = make(chan struct{}, limit)
+			c.nsLoadSems[ns] = sem
+		}
+		sems = append(sems, sem)
+	}
+	c.loadMu.Unlock()
+
+	release := func(acquired []chan struct{}) {
+		for _, sem := range acquired {
+			<-sem
+		}
+	}
+	for i, sem := range sems {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			release(sems[:i])
+			return nil, ctx.Err()
+		}
+	}
+	return func() { release(sems) }, nil
+}
+
+// isMiss reports whether an error from Get means the key has no usable value
+func isMiss(err error) bool {
+	var notFound *util.KeyNotFoundError
+	var expired *util.ExpiredKeyError
+	return errors.As(err, &notFound) || errors.As(err, &expired)
+}
@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoadRecoversLoaderPanic(t *testing.T) {
+	for _, timeout := range []time.Duration{0, time.Second} {
+		c := NewCache(CacheOpts{Capacity: 10, LoadTimeout: timeout})
+		ctx := context.Background()
+
+		_, err := c.GetOrLoad(ctx, []byte("k"), func(context.Context) ([]byte, error) {
+			panic("boom")
+		})
+		var panicErr *PanicError
+		if !errors.As(err, &panicErr) || panicErr.Value != "boom" {
+			t.Fatalf("LoadTimeout %v: got error %v, want a *PanicError", timeout, err)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			value, err := c.GetOrLoad(ctx, []byte("k"), func(context.Context) ([]byte, error) {
+				return []byte("v"), nil
+			})
+			if err != nil || string(value) != "v" {
+				t.Errorf("LoadTimeout %v: got %q, %v after a panicking load", timeout, value, err)
+			}
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("LoadTimeout %v: GetOrLoad blocked after a panicking load", timeout)
+		}
+		c.Close(ctx)
+	}
+}
+
+func TestGetOrLoadSurvivesFirstCallerCancelling(t *testing.T) {
+	for _, timeout := range []time.Duration{0, time.Second} {
+		c := NewCache(CacheOpts{Capacity: 10, LoadTimeout: timeout})
+		started, release := make(chan struct{}), make(chan struct{})
+		load := func(ctx context.Context) ([]byte, error) {
+			close(started)
+			select {
+			case <-release:
+				return []byte("v"), nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		first, cancel := context.WithCancel(context.Background())
+		firstErr := make(chan error, 1)
+		go func() {
+			_, err := c.GetOrLoad(first, []byte("k"), load)
+			firstErr <- err
+		}()
+		<-started
+
+		second := make(chan error, 1)
+		go func() {
+			value, err := c.GetOrLoad(context.Background(), []byte("k"), load)
+			if err == nil && string(value) != "v" {
+				err = errors.New("got " + string(value))
+			}
+			second <- err
+		}()
+		time.Sleep(10 * time.Millisecond) // Let the second caller join the load
+		cancel()
+		if err := <-firstErr; !errors.Is(err, context.Canceled) {
+			t.Errorf("LoadTimeout %v: cancelled caller got %v, want context.Canceled", timeout, err)
+		}
+		close(release)
+		if err := <-second; err != nil {
+			t.Errorf("LoadTimeout %v: waiter got %v after the first caller cancelled", timeout, err)
+		}
+		if !c.Has([]byte("k")) {
+			t.Errorf("LoadTimeout %v: shared load was not cached", timeout)
+		}
+		c.Close(context.Background())
+	}
+}
@@ -1,7 +1,12 @@
 package cache
 
 import (
+	"context"
+	"hash/crc32"
+	"hash/maphash"
+	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dhyanio/discache/util"
@@ -9,94 +14,666 @@ import (
 
 // CacheOpts contains the configuration options for a cache
 type CacheOpts struct {
-	Capacity int
-	TTL      time.Duration
-	OnEvict  func(key string, value []byte)
+	Capacity  int
+	TTL       time.Duration
+	OnEvict   func(key string, value []byte)
+	ChunkSize int // Values larger than this are split into chunks, 0 disables chunking
+
+	IgnoreFrozenWrites bool // Silently drop writes to a frozen cache instead of returning ErrFrozen
+
+	// NamespaceSeparator splits keys into a namespace prefix and the rest,
+	// e.g. "tenant1:user:42" is in namespace "tenant1" with ":". Keys without
+	// the separator, or all keys if it is empty, are in the "" namespace.
+	NamespaceSeparator string
+	Namespaces         map[string]NamespaceOpts // Per-namespace limits
+
+	// HashKeys stores 64-bit digests of keys instead of the keys themselves,
+	// keeping any namespace prefix. OnEvict then receives the stored digest.
+	HashKeys        bool
+	CollisionPolicy CollisionPolicy // How Puts handle a digest collision when HashKeys is set
+
+	// KeySecret makes HashKeys store truncated HMAC-SHA256 digests of keys
+	// under the secret instead of 64-bit hashes, for caches keyed by secrets
+	// such as API tokens: the live keys never sit in memory and digests do
+	// not reveal them in OnEvict, traces or dumps. Digests are the same in
+	// every cache sharing the secret.
+	KeySecret []byte
+
+	// MaxBytes is the hard limit on the total size of stored values, 0 is
+	// unlimited. Puts beyond Capacity or MaxBytes evict synchronously.
+	MaxBytes int64
+	// LowWatermark is the fraction of Capacity and MaxBytes, e.g. 0.85, that a
+	// background worker trims the cache down to every TrimInterval, keeping
+	// eviction out of the write path. 0 disables the worker.
+	LowWatermark float64
+	TrimInterval time.Duration // Defaults to one second
+	// Transform, if set, encodes values as they are stored and decodes them
+	// as they are read, e.g. GzipTransformer to compress them or
+	// AESTransformer to keep them encrypted in memory. Namespaces can set
+	// their own. Sizes and byte limits apply to encoded values.
+	Transform Transformer
+
+	// EvictionBatch is the fraction of Capacity, e.g. 0.01, evicted at once
+	// when a Put finds the cache full. 0 evicts only as much as needed.
+	EvictionBatch float64
+
+	// ErrorHandler receives internal non-fatal errors, such as panics
+	// recovered from user callbacks like OnEvict
+	ErrorHandler func(error)
+
+	// By default Has is a pure existence check. HasUpdatesStats makes it
+	// count as a hit or miss, and HasPromotes makes a found item the most
+	// recently used, as Get does.
+	HasUpdatesStats bool
+	HasPromotes     bool
+
+	// MaxConcurrentLoads bounds the loaders GetOrLoad runs at once across
+	// the cache, protecting the backing store from miss storms. 0 is unlimited.
+	MaxConcurrentLoads int
+
+	// TrackMissPenalty times GetOrLoad loaders and counts hits per
+	// namespace, for MissPenalties and LatencySaved
+	TrackMissPenalty bool
+
+	// LoadErrorTTL caches the errors of failed GetOrLoad loaders for as long,
+	// so a down dependency is not called again by every miss. 0 disables it.
+	LoadErrorTTL time.Duration
+
+	// LoadTimeout caps how long GetOrLoad waits for a loader. Past it, the
+	// key's stale value is returned if one is still stored, or ErrLoadTimeout
+	// otherwise, and the load finishes in the background. 0 waits for loads.
+	LoadTimeout time.Duration
+
+	Audit AuditSink // Receives a record of every Get, Put and Delete
+
+	// PromoteEvery and PromoteInterval reduce the order maintenance of hot
+	// items: an access only counts with the eviction policy if it is the
+	// PromoteEvery-th since the item was last promoted, or if the item was
+	// not promoted for PromoteInterval. With both 0 every access counts.
+	PromoteEvery    int
+	PromoteInterval time.Duration
+
+	// Checksums stores a CRC-32C of every value and verifies it on reads,
+	// which fail with ErrCorrupted and remove the item on a mismatch. It
+	// catches memory corruption and callers mutating slices they passed to
+	// Put or got from Get, at the cost of hashing every value read.
+	Checksums bool
+
+	// KeepDeadline makes overwriting an unexpired item keep its write time
+	// and TTL, so its expiry deadline is a hard cap on how long any of its
+	// values is served. By default an overwrite re-creates the item with a
+	// fresh TTL. The TTL passed to PutWithTTL only applies to new items then.
+	KeepDeadline bool
+
+	// Trace, if set, receives a line for every admission, rejection,
+	// promotion and eviction of a sampled key, explaining e.g. why a hot key
+	// was evicted. TraceSampleRate is the fraction of keys traced, 0 traces
+	// every key. Lines are written with the cache locked.
+	Trace           io.Writer
+	TraceSampleRate float64
+
+	// ExpectedEntries pre-sizes the cache's maps to avoid rehashing while it
+	// warms up. If 0, it is estimated as MaxBytes / ExpectedBytesPerEntry
+	// when both are set. Neither limits what the cache stores.
+	ExpectedEntries       int
+	ExpectedBytesPerEntry int
+
+	// KeyRedactor, if set, replaces keys wherever the cache exposes them for
+	// diagnostics, such as audit records. Errors returned to the caller of an
+	// operation still carry the caller's own key.
+	KeyRedactor func(key []byte) string
+
+	// MetricKeys lists keys whose hits and misses are counted individually
+	// for KeyMetrics. TopMetricKeys additionally tracks about that many of
+	// the most read other keys. Both bound the number of per-key series
+	// exported to a metrics backend; keep TopMetricKeys small, as replacing
+	// a top key scans all of them.
+	MetricKeys    [][]byte
+	TopMetricKeys int
+
+	// PressurePolicy, if set, can drop Puts of new keys while the cache is
+	// under pressure rather than evict hot items for them
+	PressurePolicy PressurePolicy
+	PressureWindow time.Duration // Window of the eviction rate, defaults to 10 seconds
+
+	// DoorkeeperRejectRate is the fraction of Puts of first-seen keys that
+	// are rejected, so one-off scans don't flush the cache. Keys requested or
+	// written before within DoorkeeperWindow are always admitted. 0 disables
+	// admission control.
+	DoorkeeperRejectRate float64
+	DoorkeeperWindow     time.Duration // Defaults to one minute
+
+	// Seed makes the cache's random choices, such as doorkeeper admissions,
+	// the same on every run, e.g. for tests and trace replays. 0 uses the
+	// global random source. Rand, if set, replaces both, e.g. for
+	// deterministic simulations.
+	Seed int64
+	Rand RandSource
+
+	TrackFrequency bool // Keep a frequency sketch of accessed keys for Frequency
+
+	// ExpiryTick enables proactive expiry with a timing wheel advanced every
+	// tick, so expired items are removed within about one tick of their
+	// deadline instead of when they are next accessed. 0 disables it.
+	ExpiryTick time.Duration
+
+	// OnExpire, if set, is notified of every item removed because its TTL
+	// elapsed, on a background goroutine. Delivery is at least once: while
+	// OnExpire returns an error or panics, the notification is retried every
+	// ExpireRetry. Notifications still pending when the process exits are
+	// lost; see PendingExpirations. Past ExpireQueueLimit pending
+	// notifications the oldest are dropped and counted in Metrics.
+	OnExpire         func(key string, value []byte) error
+	ExpireRetry      time.Duration // Defaults to one second
+	ExpireQueueLimit int           // Defaults to 10000
+
+	// LastWriteWins makes PutVersioned fail with ErrStaleWrite when the
+	// version it carries is not newer than the stored item's, so writes
+	// replicated out of order cannot replace newer values with older ones
+	LastWriteWins bool
+
+	// TombstoneTTL makes Delete remember deleted keys for as long, so fills
+	// of values read before the delete, by GetOrLoad or Fill, are dropped
+	// instead of resurrecting the deleted value. 0 disables tombstones.
+	TombstoneTTL time.Duration
+
+	// ClockResolution, if set, makes reads and writes take the time from a
+	// clock advanced by a background ticker every ClockResolution instead of
+	// calling time.Now. It saves a clock read per operation at the cost of
+	// write times and expiry checks being off by up to ClockResolution.
+	ClockResolution time.Duration
+
+	// SnapshotOnClose, if set, receives a final snapshot of the cache when
+	// it is closed, written with Snapshot.WriteTo once the background
+	// workers stopped, e.g. to warm the next process up with Restore
+	SnapshotOnClose io.Writer
+
+	// NewStore creates the store holding the cache's entries, NewMapStore if nil
+	NewStore func() Store
+	// NewPolicy creates the policy choosing which entries to evict, NewLRUPolicy if nil
+	NewPolicy func() Policy
+
+	// Group, if set, is a byte budget the cache shares with other caches.
+	// GroupWeight is the cache's share of it relative to the other members,
+	// 1 if 0.
+	Group       *Group
+	GroupWeight float64
+
+	// OnAlarm, if set, is called after every AlarmWindow in which the hit
+	// ratio fell below AlarmMinHitRatio or evictions per second rose above
+	// AlarmMaxEvictionRate. It runs on a background goroutine; forward the
+	// Alarm to a channel with a non-blocking send to handle it elsewhere.
+	OnAlarm              func(Alarm)
+	AlarmWindow          time.Duration // Defaults to one minute
+	AlarmMinHitRatio     float64       // 0 disables the hit ratio alarm
+	AlarmMaxEvictionRate float64       // 0 disables the eviction rate alarm
+
+	// StatsDAddr, if set, is the host:port of a StatsD or DogStatsD agent
+	// the cache pushes its counters and gauges to over UDP every
+	// StatsDInterval. Names are prefixed with StatsDPrefix, e.g. "cache.",
+	// and StatsDTags such as "env:prod" are sent in the DogStatsD format.
+	StatsDAddr     string
+	StatsDPrefix   string
+	StatsDTags     []string
+	StatsDInterval time.Duration // Defaults to 10 seconds
+
+	// ReadyMaxEvictionRate and ReadyMaxUsage make Ready fail while the
+	// cache evicts more items per second or uses more of its capacity, see
+	// Pressure. 0 disables either check.
+	ReadyMaxEvictionRate float64
+	ReadyMaxUsage        float64
+
+	// FullBehavior decides whether Puts to a full cache evict, fail or wait.
+	// FullTimeout bounds the wait of FullBlock, 0 waits as long as needed.
+	FullBehavior FullBehavior
+	FullTimeout  time.Duration
+
+	// Validate, if set, is consulted on every read of an item. Returning
+	// false treats the item as a miss and removes it, e.g. when a version
+	// stamp embedded in the value is outdated.
+	Validate func(key string, value []byte, meta EntryInfo) bool
 }
 
 // Cache is an in-memory key-value store with a fixed capacity and TTL
 type Cache struct {
 	CacheOpts
-	items                   map[string][]byte
-	order                   []string // Slice to maintain the LRU order
+	items                   Store
+	policy                  Policy // Chooses which entries to evict
 	mu                      sync.RWMutex
 	hits, misses, evictions int
+	expirations             int
+	shed                    int // Puts dropped by the pressure policy
+	rejected                int // Puts rejected by the doorkeeper
+	invalidations           int // Items removed because Validate rejected them
+	drift                   int // Inconsistencies repaired by Verify
+	doorkeeper              *doorkeeper
+	rng                     RandSource // Randomness from Seed or Rand, nil for the global source
+	sketch                  *countMinSketch
+	wheel                   *timingWheel
+	windowStart             time.Time
+	windowEvictions         int     // Evictions at the start of the pressure window
+	evictionRate            float64 // Eviction rate of the last full pressure window
 	timestamps              map[string]time.Time
+	ttls                    map[string]time.Duration // Per-item TTLs overriding the cache TTL
+	chunks                  map[string][]string      // Manifest key to its chunk keys
+	chunkOwner              map[string]string        // Chunk key to its manifest key
+	nsBytes                 map[string]int64         // Bytes stored per namespace
+	bytes                   atomic.Int64             // Bytes stored in total, read without locking
+	entries                 atomic.Int64             // Items stored, excluding chunks, read without locking
+	member                  *groupMember             // Accounting in CacheOpts.Group
+	generations             map[string]uint64        // Namespace generation entries were written in, if not 0
+	checksums               map[string]uint32        // CRC-32C of every entry with Checksums
+	promotions              map[string]promotion     // Accesses since the last promotion of items
+	nsGens                  map[string]uint64        // Current generation per namespace
+	limitMu                 sync.Mutex
+	buckets                 map[string]*tokenBucket // Rate limiters per namespace
+	keySeed, fpSeed         maphash.Seed            // Seeds of key digests and fingerprints
+	fingerprints            map[string]uint64       // Fingerprints of the original keys of hashed keys
+	loadMu                  sync.Mutex
+	calls                   map[string]*loadCall     // In-flight GetOrLoad loads
+	loadErrors              map[string]loadError     // Recently failed GetOrLoad loads
+	penalties               map[string]*MissPenalty  // Miss penalty per namespace
+	loadSem                 chan struct{}            // Cache-wide loader slots
+	nsLoadSems              map[string]chan struct{} // Per-namespace loader slots
+	roomFreed               chan struct{}            // Closed when an item is removed while Puts wait for room
+	reservations            map[string]reservation   // Placeholders of reserved keys
+	holds                   map[string]capacityHold  // Room reserved per namespace with ReserveCapacity
+	dependencies            map[string]keySet        // Items every item depends on
+	tombstones              map[string]time.Time     // Deletion times of recently deleted keys
+	versions                map[string]uint64        // Version of every item's last write
+	lastVersion             uint64                   // Latest version assigned or received
+	lastSweep               time.Time                // Last sweep of expired tombstones
+	dependents              map[string]keySet        // Items depending on every item
+	lastToken               Reservation              // Token of the latest reservation
+	created                 time.Time                // When the cache was created
+	expiredMu               sync.Mutex               // Guards expiredQueue and expiredDropped
+	expiredQueue            []expiration             // OnExpire notifications waiting to be delivered
+	expiredDropped          int                      // OnExpire notifications dropped past ExpireQueueLimit
+	expiredSignal           chan struct{}            // Wakes the notification worker
+	lastExpiry, lastTrim    time.Time                // Last runs of the background workers
+	keyCounters             map[string]*keyCounter   // Read counters of the keys tracked for KeyMetrics
+	topKeys                 int                      // Number of top keys tracked
+	clock                   atomic.Int64             // Unix nanoseconds of the coarse clock with ClockResolution
+	trimming                bool                     // Whether the watermark trimmer was started
+	scanIndex               *scanIndex               // Keys by hash for Scan, built by the first Scan
+	frozen                  bool
+	closed                  bool
+	done                    chan struct{}  // Closed to stop background workers
+	workers                 sync.WaitGroup // Tracks running background workers
 }
 
 // NewCache creates a new cache with the specified capacity, TTL, and eviction callback
 func NewCache(opts CacheOpts) *Cache {
-	return &Cache{
-		CacheOpts:  opts,
-		items:      make(map[string][]byte),
-		order:      []string{},
-		timestamps: make(map[string]time.Time),
+	c := &Cache{
+		CacheOpts:    opts,
+		rng:          newRand(opts),
+		created:      time.Now(),
+		timestamps:   make(map[string]time.Time),
+		ttls:         make(map[string]time.Duration),
+		chunks:       make(map[string][]string),
+		chunkOwner:   make(map[string]string),
+		nsBytes:      make(map[string]int64),
+		generations:  make(map[string]uint64),
+		checksums:    make(map[string]uint32),
+		promotions:   make(map[string]promotion),
+		reservations: make(map[string]reservation),
+		dependencies: make(map[string]keySet),
+		tombstones:   make(map[string]time.Time),
+		versions:     make(map[string]uint64),
+		dependents:   make(map[string]keySet),
+		nsGens:       make(map[string]uint64),
+		buckets:      make(map[string]*tokenBucket),
+		keySeed:      maphash.MakeSeed(),
+		fpSeed:       maphash.MakeSeed(),
+		fingerprints: make(map[string]uint64),
+		calls:        make(map[string]*loadCall),
+		loadErrors:   make(map[string]loadError),
+		penalties:    make(map[string]*MissPenalty),
+		loadSem:      make(chan struct{}, max(opts.MaxConcurrentLoads, 0)),
+		nsLoadSems:   make(map[string]chan struct{}),
+		done:         make(chan struct{}),
+	}
+	if opts.NewStore != nil {
+		c.items = opts.NewStore()
+	} else {
+		c.items = NewMapStore()
+	}
+	if opts.NewPolicy != nil {
+		c.policy = opts.NewPolicy()
+	} else {
+		c.policy = NewLRUPolicy()
+	}
+	c.presize(opts.expectedEntries())
+	c.trackKeys()
+	if opts.Group != nil {
+		c.member = opts.Group.join(c)
+	}
+	if opts.DoorkeeperRejectRate > 0 {
+		c.doorkeeper = newDoorkeeper(opts.Capacity, c.newHash())
+	}
+	if opts.TrackFrequency {
+		c.sketch = newCountMinSketch(opts.Capacity, c.newHash())
+	}
+	if opts.ClockResolution > 0 {
+		c.clock.Store(time.Now().UnixNano())
+		c.startWorker(c.clockLoop)
+	}
+	if opts.OnExpire != nil {
+		c.expiredSignal = make(chan struct{}, 1)
+		c.startWorker(c.notifyLoop)
+	}
+	if opts.ExpiryTick > 0 {
+		c.wheel = newTimingWheel(opts.ExpiryTick)
+		c.startWorker(c.expiryLoop)
+	}
+	if opts.LowWatermark > 0 {
+		c.trimming = true
+		c.startWorker(c.trimLoop)
+	}
+	if opts.OnAlarm != nil {
+		c.startWorker(c.alarmLoop)
 	}
+	if opts.StatsDAddr != "" {
+		c.startWorker(c.statsdLoop)
+	}
+	return c
+}
+
+// expectedEntries returns the number of entries to size the maps for
+func (opts CacheOpts) expectedEntries() int {
+	n := opts.ExpectedEntries
+	if n <= 0 && opts.MaxBytes > 0 && opts.ExpectedBytesPerEntry > 0 {
+		n = int(opts.MaxBytes / int64(opts.ExpectedBytesPerEntry))
+	}
+	if opts.Capacity > 0 {
+		n = min(n, opts.Capacity)
+	}
+	return n
 }
 
-// Get retrieves an item from the cache and updates its usage
+// Get retrieves an item from the cache and updates its usage. Empty values
+// are valid: a stored nil or empty value is returned as a non-nil empty
+// slice, so a nil value is only ever returned together with an error.
 func (c *Cache) Get(key []byte) ([]byte, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	return c.GetContext(context.Background(), key)
+}
 
-	strKey := string(key)
+// GetContext is Get with a context carrying the request ID for the audit log
+func (c *Cache) GetContext(ctx context.Context, key []byte) (value []byte, err error) {
+	if c.CacheOpts.Audit != nil {
+		defer func(start time.Time) { c.audit(ctx, AuditGet, key, len(value), start, "hit", err) }(time.Now())
+	}
+	if err := c.limit(ctx, key); err != nil {
+		return nil, err
+	}
 
-	if value, found := c.items[strKey]; found {
-		if c.CacheOpts.TTL > 0 && time.Since(c.timestamps[strKey]) > c.CacheOpts.TTL {
-			c.mu.RUnlock()
-			c.mu.Lock()
-			c.remove(strKey) // Expire the item if TTL has elapsed
-			c.mu.Unlock()
-			c.mu.RLock()
-			c.misses++
-			return nil, &util.ExpiredKeyError{Key: strKey}
-		}
-		c.hits++
-		c.updateOrder(strKey) // Move the accessed key to the end of the order slice
-		return value, nil
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, ErrClosed
+	}
+
+	strKey, err := c.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	return c.read(strKey)
+}
+
+// lookup finds the item to return for a read, updating stats and recency,
+// and returns its storage key
+func (c *Cache) lookup(key []byte) (_ string, err error) {
+	strKey := c.storageKey(key)
+	c.recordAccess(strKey)
+	defer func() { c.countKey(strKey, err == nil) }()
+
+	if _, found := c.items.Get(strKey); !found || c.collides(strKey, key) {
+		c.misses++
+		c.sighted(strKey)
+		return "", &util.KeyNotFoundError{Key: string(key)}
+	}
+	if c.expired(strKey) {
+		c.expire(strKey) // Expire the item if TTL has elapsed
+		c.misses++
+		return "", &util.ExpiredKeyError{Key: string(key)}
+	}
+	if !c.intact(strKey) {
+		c.trace(strKey, "evict", "checksum")
+		c.remove(strKey)
+		c.misses++
+		return "", ErrCorrupted
 	}
-	c.misses++
-	return nil, &util.KeyNotFoundError{Key: strKey}
+	if !c.valid(strKey) {
+		c.trace(strKey, "evict", "validate")
+		c.remove(strKey)
+		c.invalidations++
+		c.misses++
+		return "", &util.KeyNotFoundError{Key: string(key)}
+	}
+	c.hits++
+	if c.CacheOpts.TrackMissPenalty {
+		c.penaltyOf(strKey).Hits++
+	}
+	c.touch(strKey) // Record the access with the eviction policy
+	c.slide(strKey)
+	return strKey, nil
+}
+
+// Lookup retrieves an item from the cache and updates its usage, reporting
+// whether it was found. ok is false on a miss, an expired item, or any other
+// error that Get would return.
+func (c *Cache) Lookup(key []byte) (value []byte, ok bool) {
+	value, err := c.Get(key)
+	return value, err == nil
 }
 
 // Put inserts an item into the cache and updates its usage
 func (c *Cache) Put(key, value []byte) error {
+	return c.PutWithTTL(key, value, 0)
+}
+
+// PutContext is Put with a context carrying the request ID for the audit log
+func (c *Cache) PutContext(ctx context.Context, key, value []byte) error {
+	return c.write(ctx, key, value, writeOpts{})
+}
+
+// PutWithTTL inserts an item into the cache with its own TTL, overriding the
+// cache TTL, and updates its usage. A ttl of 0 uses the cache TTL.
+func (c *Cache) PutWithTTL(key, value []byte, ttl time.Duration) error {
+	return c.write(context.Background(), key, value, writeOpts{ttl: ttl})
+}
+
+// writeOpts are the optional parameters of a write
+type writeOpts struct {
+	ttl     time.Duration // 0 uses the cache TTL
+	token   Reservation   // Reservation of the writer, 0 for none
+	readAt  time.Time     // When a filled value was read, zero for an authoritative write
+	version uint64        // Version carried by the write, 0 to assign one
+}
+
+// write is PutWithTTL with optional parameters
+func (c *Cache) write(ctx context.Context, key, value []byte, w writeOpts) error {
+	return c.writeWith(ctx, key, len(value), w, func(strKey string) (int, error) {
+		return len(value), c.put(strKey, value)
+	})
+}
+
+// writeWith runs the checks and bookkeeping every write goes through, and
+// stores the value with store, which returns the size it stored. size is
+// the expected size of the value, used to wait for room under FullBlock.
+func (c *Cache) writeWith(ctx context.Context, key []byte, size int, w writeOpts, store func(strKey string) (int, error)) (err error) {
+	if c.CacheOpts.Audit != nil {
+		defer func(start time.Time) { c.audit(ctx, AuditPut, key, size, start, "stored", err) }(time.Now())
+	}
+	if err := c.limit(ctx, key); err != nil {
+		return err
+	}
+
+	defer c.reclaimGroup() // Runs after unlocking
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	strKey := string(key)
+	if c.closed {
+		return ErrClosed
+	}
+	if c.frozen {
+		return c.frozenError()
+	}
 
-	if _, found := c.items[strKey]; found {
-		c.items[strKey] = value
-		c.timestamps[strKey] = time.Now()
-		c.updateOrder(strKey)
+	strKey := c.storageKey(key)
+	if err := c.awaitRoom(strKey, size); err != nil {
+		return err
+	}
+	if err := c.checkReservation(strKey, w.token); err != nil {
+		return err
+	}
+	if err := c.checkTombstone(strKey, w.readAt); err != nil {
+		return err
+	}
+	if err := c.checkVersion(strKey, key, w.version); err != nil {
+		return err
+	}
+	if err := c.checkCollision(strKey, key); err != nil {
+		return err
+	}
+	c.recordAccess(strKey)
+	if c.shouldShed(strKey) {
+		c.trace(strKey, "reject", "pressure")
+		c.shed++
+		return nil
+	}
+	if !c.admit(strKey) {
+		c.trace(strKey, "reject", "doorkeeper")
+		c.rejected++
 		return nil
 	}
+	restore := c.keepDeadline(strKey, key)
+	if size, err = store(strKey); err != nil {
+		return err
+	}
+	delete(c.reservations, strKey) // The holder's write fulfils the reservation
+	c.trace(strKey, "admit", "")
+	if w.ttl > 0 {
+		c.setTTL(strKey, w.ttl)
+	}
+	restore()
+	c.receiveVersion(strKey, w.version)
+	c.setFingerprint(strKey, key)
+	return nil
+}
 
-	// Evict the least recently used item if capacity is reached
-	if len(c.items) >= c.CacheOpts.Capacity {
-		c.evict()
+// put stores an item under its storage key and updates its usage
+func (c *Cache) put(strKey string, value []byte) error {
+	value, err := c.encode(strKey, value)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		value = []byte{} // Keep stored values distinguishable from misses
+	}
+	if c.CacheOpts.ChunkSize > 0 && len(value) > c.CacheOpts.ChunkSize {
+		return c.putChunked(strKey, value)
+	}
+	if !c.fits(1, int64(len(value))) {
+		return ErrValueTooLarge
+	}
+	if err := c.enforceQuota(strKey, len(value)); err != nil {
+		return err
 	}
 
-	c.items[strKey] = value
-	c.timestamps[strKey] = time.Now()
-	c.order = append(c.order, strKey) // Add key to the end of order slice
+	// An overwritten item is replaced as a whole and becomes the most recently used
+	if _, found := c.items.Get(strKey); found {
+		c.dropChunks(strKey)
+		c.drop(strKey)
+	}
+
+	// Evict least recently used items if capacity is reached
+	c.makeRoom(c.namespaceOf(strKey), 1, int64(len(value)))
+
+	c.insert(strKey, value, c.now())
 	return nil
 }
 
-// Has checks if a key exists in the cache
-func (c *Cache) Has(key []byte) bool {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+// insert stores a new unchunked item as the most recently used
+func (c *Cache) insert(strKey string, value []byte, now time.Time) {
+	c.setItem(strKey, value)
+	c.timestamps[strKey] = now
+	c.stamp(strKey, now)
+	c.policy.RecordInsert(strKey)
+	c.reschedule(strKey)
+}
 
-	strKey := string(key)
-	if _, found := c.items[strKey]; found {
-		if c.CacheOpts.TTL > 0 && time.Since(c.timestamps[strKey]) > c.CacheOpts.TTL {
-			return false
+// Has checks if a key exists in the cache. It does not affect stats or
+// recency unless HasUpdatesStats or HasPromotes is set.
+func (c *Cache) Has(key []byte) (found bool) {
+	if c.CacheOpts.Audit != nil {
+		defer func(start time.Time) {
+			outcome := "miss"
+			if found {
+				outcome = "hit"
+			}
+			c.audit(context.Background(), AuditGet, key, 0, start, outcome, nil)
+		}(time.Now())
+	}
+	if c.CacheOpts.HasUpdatesStats || c.CacheOpts.HasPromotes {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+	} else {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+	}
+
+	if c.closed {
+		return false
+	}
+
+	strKey := c.storageKey(key)
+	_, found = c.items.Get(strKey)
+	found = found && !c.collides(strKey, key) && !c.expired(strKey)
+	if c.CacheOpts.HasUpdatesStats {
+		if found {
+			c.hits++
+		} else {
+			c.misses++
 		}
-		return true
 	}
-	return false
+	if found && c.CacheOpts.HasPromotes {
+		c.touch(strKey)
+	}
+	return found
+}
+
+// Delete removes an item from the cache and reports whether it was present
+func (c *Cache) Delete(key []byte) bool {
+	return c.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext is Delete with a context carrying the request ID for the audit log
+func (c *Cache) DeleteContext(ctx context.Context, key []byte) (deleted bool) {
+	if c.CacheOpts.Audit != nil {
+		defer func(start time.Time) {
+			outcome := "absent"
+			if deleted {
+				outcome = "deleted"
+			}
+			c.audit(ctx, AuditDelete, key, 0, start, outcome, nil)
+		}(time.Now())
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed || c.frozen {
+		return false
+	}
+
+	strKey := c.storageKey(key)
+	c.bury(strKey) // Even if absent, a fill may be in flight
+	if _, found := c.items.Get(strKey); !found || c.collides(strKey, key) {
+		return false
+	}
+	c.remove(strKey)
+	return true
 }
 
 // Stats returns the cache hit, miss, and eviction counts
@@ -106,43 +683,192 @@ func (c *Cache) Stats() (hits, misses, evictions int) {
 	return c.hits, c.misses, c.evictions
 }
 
-// evict removes the least recently used item from the cache
-func (c *Cache) evict() {
-	if len(c.order) == 0 {
+// fits reports whether the given number of entries and bytes can fit in an empty cache
+func (c *Cache) fits(entries int, size int64) bool {
+	if entries > 1 && entries > c.CacheOpts.Capacity {
+		return false
+	}
+	return c.CacheOpts.MaxBytes <= 0 || size <= c.CacheOpts.MaxBytes
+}
+
+// full reports whether adding the given number of entries and bytes would
+// exceed Capacity or MaxBytes
+func (c *Cache) full(ns string, entries int, size int64) bool {
+	return c.items.Len()+entries > c.CacheOpts.Capacity ||
+		(c.CacheOpts.MaxBytes > 0 && c.bytes.Load()+size+c.heldBytes(ns, size) > c.CacheOpts.MaxBytes)
+}
+
+// makeRoom evicts least recently used items until the given number of
+// entries and bytes, written to namespace ns, fit under Capacity and
+// MaxBytes, less the room reserved for other namespaces
+func (c *Cache) makeRoom(ns string, entries int, size int64) {
+	if !c.full(ns, entries, size) {
+		return
+	}
+	// Evict at least a whole batch to amortize eviction over the next Puts
+	batch := int(float64(c.CacheOpts.Capacity) * c.CacheOpts.EvictionBatch)
+	for evicted := 0; c.items.Len() > 0 && (evicted < batch || c.full(ns, entries, size)); evicted++ {
+		c.evict("capacity")
+	}
+}
+
+// expired reports whether the TTL of an item has elapsed, its namespace
+// generation was bumped since it was written, or an item it depends on expired
+func (c *Cache) expired(key string) bool {
+	return c.expiredItself(key) || c.dependencyExpired(key)
+}
+
+// expiredItself is expired regardless of the item's dependencies
+func (c *Cache) expiredItself(key string) bool {
+	if c.outdated(key) {
+		return true
+	}
+	ttl := c.ttlOf(key)
+	return ttl > 0 && c.now().Sub(c.timestamps[key]) > ttl
+}
+
+// setTTL gives an item its own TTL
+func (c *Cache) setTTL(key string, ttl time.Duration) {
+	c.ttls[key] = ttl
+	c.reschedule(key)
+}
+
+// ttlOf returns the TTL of an item, 0 if it never expires
+func (c *Cache) ttlOf(key string) time.Duration {
+	if ttl, ok := c.ttls[key]; ok {
+		return ttl
+	}
+	if len(c.CacheOpts.Namespaces) > 0 {
+		if ttl := c.CacheOpts.Namespaces[c.namespaceOf(key)].TTL; ttl > 0 {
+			return ttl
+		}
+	}
+	return c.CacheOpts.TTL
+}
+
+// evict removes the least recently used item from the cache, sparing items
+// within their namespace's reserved capacity, tracing the reason it had to go
+func (c *Cache) evict(reason string) {
+	victim, ok := c.policy.Victim()
+	if !ok {
 		return
 	}
-	oldestKey := c.order[0]
-	c.remove(oldestKey)
+	if len(c.holds) > 0 {
+		if unreserved := c.unreservedVictim(); unreserved != "" {
+			victim = unreserved
+		}
+	}
+	if _, found := c.items.Get(victim); !found {
+		c.policy.Remove(victim) // Not stored, nothing to evict
+		return
+	}
+	c.trace(victim, "evict", reason)
+	c.remove(victim)
 	c.evictions++
 }
 
-// remove deletes an item from the cache
+// expire removes an item whose TTL has elapsed
+func (c *Cache) expire(key string) {
+	c.trace(key, "evict", "ttl")
+	c.queueExpiration(key)
+	c.remove(key)
+	c.expirations++
+}
+
+// remove deletes an item from the cache, along with all of its chunks
 func (c *Cache) remove(key string) {
-	if value, found := c.items[key]; found {
-		delete(c.items, key)
-		delete(c.timestamps, key)
+	if owner, ok := c.chunkOwner[key]; ok {
+		key = owner // Removing any chunk removes the whole value
+	}
+	if value, found := c.items.Get(key); found {
+		if chunkKeys, ok := c.chunks[key]; ok {
+			value = c.joinChunks(chunkKeys)
+			c.dropChunks(key)
+		}
+		c.drop(key)
 		if c.CacheOpts.OnEvict != nil {
-			c.CacheOpts.OnEvict(key, value)
+			value = c.plain(key, value)
+			c.callback("OnEvict", func() { c.CacheOpts.OnEvict(key, value) })
 		}
-		// Remove the key from the order slice
-		for i, k := range c.order {
-			if k == key {
-				c.order = append(c.order[:i], c.order[i+1:]...)
-				break
-			}
+	}
+}
+
+// setItem stores the value of a single entry and accounts for its size
+func (c *Cache) setItem(key string, value []byte) {
+	old, found := c.items.Get(key)
+	if _, isChunk := c.chunkOwner[key]; !found && !isChunk {
+		c.entries.Add(1)
+		if c.scanIndex != nil {
+			c.scanIndex.add(key)
 		}
 	}
+	c.addBytes(key, int64(len(value)-len(old)))
+	c.items.Set(key, value)
+	c.setGeneration(key)
+	if c.CacheOpts.Checksums {
+		c.checksums[key] = crc32.Checksum(value, castagnoli)
+	}
 }
 
-// updateOrder moves a key to the end of the LRU order slice
-func (c *Cache) updateOrder(key string) {
-	for i, k := range c.order {
-		if k == key {
-			// Remove the key from its current position
-			c.order = append(c.order[:i], c.order[i+1:]...)
-			break
+// addBytes accounts for a change in the size of an entry
+func (c *Cache) addBytes(key string, delta int64) {
+	c.nsBytes[c.namespaceOf(key)] += delta
+	c.bytes.Add(delta)
+	if c.member != nil {
+		c.CacheOpts.Group.add(c.member, delta)
+	}
+}
+
+// drop deletes a single entry and its bookkeeping without notifying OnEvict
+func (c *Cache) drop(key string) {
+	value, found := c.items.Get(key)
+	if _, isChunk := c.chunkOwner[key]; found && !isChunk {
+		c.entries.Add(-1)
+		if c.scanIndex != nil {
+			c.scanIndex.remove(key)
 		}
 	}
-	// Add the key to the end to mark it as recently used
-	c.order = append(c.order, key)
+	c.addBytes(key, -int64(len(value)))
+	c.items.Delete(key)
+	delete(c.timestamps, key)
+	delete(c.ttls, key)
+	delete(c.fingerprints, key)
+	delete(c.generations, key)
+	delete(c.checksums, key)
+	delete(c.promotions, key)
+	delete(c.versions, key)
+	if c.wheel != nil {
+		c.wheel.cancel(key)
+	}
+	c.policy.Remove(key)
+	c.freeRoom()
+	c.dropDependencies(key)
+}
+
+// castagnoli is the CRC-32C table of entry checksums
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// intact verifies the checksums of an item and its chunks
+func (c *Cache) intact(key string) bool {
+	if !c.CacheOpts.Checksums {
+		return true
+	}
+	for _, k := range append([]string{key}, c.chunks[key]...) {
+		if sum, ok := c.checksums[k]; ok && sum != crc32.Checksum(c.stored(k), castagnoli) {
+			return false
+		}
+	}
+	return true
+}
+
+// touch records an access to an item and its chunks with the eviction policy
+func (c *Cache) touch(key string) {
+	if !c.promote(key) {
+		return
+	}
+	c.trace(key, "promote", "")
+	for _, chunkKey := range c.chunks[key] {
+		c.policy.RecordAccess(chunkKey)
+	}
+	c.policy.RecordAccess(key)
 }
@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNilAndEmptyValuesAreFound(t *testing.T) {
+	c := NewCache(CacheOpts{Capacity: 10})
+	defer c.Close(context.Background())
+
+	for name, value := range map[string][]byte{"nil": nil, "empty": {}} {
+		key := []byte(name)
+		if err := c.Put(key, value); err != nil {
+			t.Fatalf("Put %s: %v", name, err)
+		}
+		got, err := c.Get(key)
+		if err != nil || got == nil || len(got) != 0 {
+			t.Errorf("Get %s = %#v, %v; want a non-nil empty slice", name, got, err)
+		}
+		got, ok := c.Lookup(key)
+		if !ok || got == nil || len(got) != 0 {
+			t.Errorf("Lookup %s = %#v, %v; want a non-nil empty slice", name, got, ok)
+		}
+	}
+
+	if got, err := c.Get([]byte("missing")); err == nil || !isMiss(err) || got != nil {
+		t.Errorf("Get missing = %#v, %v; want a miss", got, err)
+	}
+	if got, ok := c.Lookup([]byte("missing")); ok || got != nil {
+		t.Errorf("Lookup missing = %#v, %v; want not found", got, ok)
+	}
+}
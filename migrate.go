@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/dhyanio/discache/util"
+)
+
+// RemoteCache is the external cache a MigrationCache migrates from, e.g. a
+// thin adapter over a Redis client. Get reports a missing key with found
+// false rather than an error.
+type RemoteCache interface {
+	Get(ctx context.Context, key []byte) (value []byte, found bool, err error)
+	Set(ctx context.Context, key, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key []byte) error
+}
+
+// ReadPrecedence decides which cache a MigrationCache reads first
+type ReadPrecedence int32
+
+const (
+	// ReadRemoteFirst reads the remote cache, falling back to the local one
+	ReadRemoteFirst ReadPrecedence = iota
+	// ReadLocalFirst reads the local cache, falling back to the remote one
+	// and copying its hits into the local cache
+	ReadLocalFirst
+	// ReadLocalOnly reads and writes the local cache alone, once the
+	// migration is done
+	ReadLocalOnly
+)
+
+// MigrationCache moves an application off an external cache incrementally.
+// Writes go to both caches and reads follow a precedence that can be changed
+// while running. Disable is a kill switch sending every operation to the
+// remote cache alone, as before the migration started.
+type MigrationCache struct {
+	local      *Cache
+	remote     RemoteCache
+	precedence atomic.Int32
+	disabled   atomic.Bool
+}
+
+// NewMigrationCache creates a cache writing to local and remote, reading
+// with the given precedence
+func NewMigrationCache(local *Cache, remote RemoteCache, precedence ReadPrecedence) *MigrationCache {
+	m := &MigrationCache{local: local, remote: remote}
+	m.precedence.Store(int32(precedence))
+	return m
+}
+
+// SetPrecedence changes the order in which caches are read
+func (m *MigrationCache) SetPrecedence(p ReadPrecedence) {
+	m.precedence.Store(int32(p))
+}
+
+// Precedence returns the order in which caches are read
+func (m *MigrationCache) Precedence() ReadPrecedence {
+	return ReadPrecedence(m.precedence.Load())
+}
+
+// Disable stops using the local cache until Enable is called
+func (m *MigrationCache) Disable() {
+	m.disabled.Store(true)
+}
+
+// Enable resumes using the local cache after Disable. Writes made while it
+// was disabled are missing from it, so it may serve stale values until they
+// expire.
+func (m *MigrationCache) Enable() {
+	m.disabled.Store(false)
+}
+
+// Disabled reports whether the kill switch is on
+func (m *MigrationCache) Disabled() bool {
+	return m.disabled.Load()
+}
+
+// Get retrieves an item following the read precedence. A miss or an error
+// of the first cache falls back to the second. Misses return a
+// *util.KeyNotFoundError.
+func (m *MigrationCache) Get(ctx context.Context, key []byte) ([]byte, error) {
+	if m.disabled.Load() {
+		return m.getRemote(ctx, key)
+	}
+	switch m.Precedence() {
+	case ReadLocalOnly:
+		return m.local.GetContext(ctx, key)
+	case ReadLocalFirst:
+		value, err := m.local.GetContext(ctx, key)
+		if err == nil {
+			return value, nil
+		}
+		value, remoteErr := m.getRemote(ctx, key)
+		if remoteErr != nil {
+			return nil, err
+		}
+		if err := m.local.PutContext(ctx, key, value); err != nil {
+			m.local.reportError(err)
+		}
+		return value, nil
+	default:
+		value, err := m.getRemote(ctx, key)
+		if err == nil {
+			return value, nil
+		}
+		if value, localErr := m.local.GetContext(ctx, key); localErr == nil {
+			return value, nil
+		}
+		return nil, err
+	}
+}
+
+// getRemote reads the remote cache, turning a missing key into an error
+func (m *MigrationCache) getRemote(ctx context.Context, key []byte) ([]byte, error) {
+	value, found, err := m.remote.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &util.KeyNotFoundError{Key: string(key)}
+	}
+	return value, nil
+}
+
+// Put stores an item in both caches with the given TTL, 0 for each cache's
+// default. The remote cache's error is returned first, since it is the
+// source of truth until the migration is done; a local error is reported
+// to the local cache's ErrorHandler.
+func (m *MigrationCache) Put(ctx context.Context, key, value []byte, ttl time.Duration) error {
+	if m.disabled.Load() {
+		return m.remote.Set(ctx, key, value, ttl)
+	}
+	if m.Precedence() == ReadLocalOnly {
+		return m.local.write(ctx, key, value, writeOpts{ttl: ttl})
+	}
+	if err := m.remote.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	if err := m.local.write(ctx, key, value, writeOpts{ttl: ttl}); err != nil {
+		m.local.reportError(err)
+	}
+	return nil
+}
+
+// Delete removes an item from both caches. The local cache is updated even
+// while disabled, so it holds no deleted values when enabled again.
+func (m *MigrationCache) Delete(ctx context.Context, key []byte) error {
+	m.local.DeleteContext(ctx, key)
+	if !m.disabled.Load() && m.Precedence() == ReadLocalOnly {
+		return nil
+	}
+	return m.remote.Delete(ctx, key)
+}
@@ -0,0 +1,164 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/dhyanio/discache/util"
+)
+
+// GetOrLoadMulti retrieves several items, calling load once with all the
+// keys that missed. Hits are not passed to load, and the loaded values are
+// cached and returned with the hits, keyed by string(key). Keys missing from
+// both are absent from the result. Missing keys already being loaded by
+// GetOrLoad or another GetOrLoadMulti wait for that load instead, and
+// GetOrLoad calls of the batch's keys wait for the batch. Like GetOrLoad's
+// loads, the batch runs on a context detached from the caller's, which only
+// stops waiting for it. Loads are bounded by MaxConcurrentLoads and the
+// MaxConcurrentLoads of the first missing key's namespace, and their errors
+// are remembered for LoadErrorTTL like GetOrLoad's.
+func (c *Cache) GetOrLoadMulti(ctx context.Context, keys [][]byte, load func(ctx context.Context, missing [][]byte) (map[string][]byte, error)) (map[string][]byte, error) {
+	values := make(map[string][]byte, len(keys))
+	seen := make(map[string]bool, len(keys))
+	var missing [][]byte
+	for _, key := range keys {
+		if seen[string(key)] {
+			continue
+		}
+		seen[string(key)] = true
+		value, err := c.GetContext(ctx, key)
+		if err != nil && !isMiss(err) {
+			return nil, err
+		}
+		if err != nil {
+			missing = append(missing, key)
+			continue
+		}
+		values[string(key)] = value
+	}
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	calls, claimed, err := c.claimLoads(missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(claimed) > 0 {
+		// The batch is shared with callers joining it, so it outlives this one
+		go c.runBatch(context.WithoutCancel(ctx), claimed, calls, load)
+	}
+	for _, key := range missing {
+		call := calls[string(key)]
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if call.err != nil && !isMiss(call.err) {
+			return nil, call.err
+		}
+		if call.err == nil {
+			values[string(key)] = call.value
+		}
+	}
+	return values, nil
+}
+
+// claimLoads returns the load of every missing key, joining those in flight
+// and starting the others, which are returned as claimed for the caller to
+// load. A remembered load error of any key fails the whole call.
+func (c *Cache) claimLoads(missing [][]byte) (calls map[string]*loadCall, claimed [][]byte, err error) {
+	c.loadMu.Lock()
+	defer c.loadMu.Unlock()
+
+	now := c.now()
+	for _, key := range missing {
+		if failed, ok := c.loadErrors[string(key)]; ok {
+			if now.Before(failed.expires) {
+				return nil, nil, failed.err
+			}
+			delete(c.loadErrors, string(key))
+		}
+	}
+	calls = make(map[string]*loadCall, len(missing))
+	for _, key := range missing {
+		strKey := string(key)
+		call, ok := c.calls[strKey]
+		if !ok {
+			call = &loadCall{done: make(chan struct{})}
+			c.calls[strKey] = call
+			claimed = append(claimed, key)
+		}
+		calls[strKey] = call
+	}
+	return calls, claimed, nil
+}
+
+// runBatch runs a batch loader for the claimed keys and publishes each
+// key's result to its call. Keys the loader does not return fail with a
+// *util.KeyNotFoundError, and a panicking loader fails them all with a
+// *PanicError.
+func (c *Cache) runBatch(ctx context.Context, claimed [][]byte, calls map[string]*loadCall, load func(ctx context.Context, missing [][]byte) (map[string][]byte, error)) {
+	var values map[string][]byte
+	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Callback: "GetOrLoadMulti", Value: r, Stack: debug.Stack()}
+		}
+		c.loadMu.Lock()
+		defer c.loadMu.Unlock()
+		for _, key := range claimed {
+			strKey := string(key)
+			call := calls[strKey]
+			if value, ok := values[strKey]; err == nil && ok {
+				call.value = value
+			} else if err == nil {
+				call.err = &util.KeyNotFoundError{Key: strKey}
+			} else {
+				call.err = err
+				if ttl := c.CacheOpts.LoadErrorTTL; ttl > 0 {
+					c.loadErrors[strKey] = loadError{err: err, expires: c.now().Add(ttl)}
+				}
+			}
+			delete(c.calls, strKey)
+			close(call.done)
+		}
+	}()
+	values, err = c.loadBatch(ctx, claimed, load)
+}
+
+// loadBatch runs a batch loader once a load slot is available and caches
+// the values it returns for the requested keys. For miss penalties each key
+// counts as one load taking an equal share of the batch.
+func (c *Cache) loadBatch(ctx context.Context, missing [][]byte, load func(ctx context.Context, missing [][]byte) (map[string][]byte, error)) (map[string][]byte, error) {
+	release, err := c.acquireLoadSlots(ctx, c.namespaceOf(string(missing[0])))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	readAt := c.now()
+	start := time.Now()
+	values, err := load(ctx, missing)
+	took := time.Since(start) / time.Duration(len(missing))
+	for _, key := range missing {
+		c.recordLoad(c.storageKey(key), took)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range missing {
+		value, ok := values[string(key)]
+		if !ok {
+			continue
+		}
+		if err := c.write(ctx, key, value, writeOpts{readAt: readAt}); err != nil && !errors.Is(err, ErrStaleFill) {
+			c.reportError(fmt.Errorf("storing loaded value: %w", err))
+		}
+	}
+	return values, nil
+}
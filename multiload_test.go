@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoadMultiSurvivesCallerCancelling(t *testing.T) {
+	c := NewCache(CacheOpts{Capacity: 10})
+	defer c.Close(context.Background())
+	started, release := make(chan struct{}), make(chan struct{})
+	load := func(ctx context.Context, missing [][]byte) (map[string][]byte, error) {
+		close(started)
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		values := make(map[string][]byte, len(missing))
+		for _, key := range missing {
+			values[string(key)] = append([]byte("v-"), key...)
+		}
+		return values, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	batchErr := make(chan error, 1)
+	go func() {
+		_, err := c.GetOrLoadMulti(ctx, [][]byte{[]byte("a"), []byte("b")}, load)
+		batchErr <- err
+	}()
+	<-started
+
+	joined := make(chan error, 1)
+	go func() {
+		value, err := c.GetOrLoad(context.Background(), []byte("b"), func(context.Context) ([]byte, error) {
+			return nil, errors.New("the batch should have loaded b")
+		})
+		if err == nil && string(value) != "v-b" {
+			err = errors.New("got " + string(value))
+		}
+		joined <- err
+	}()
+	time.Sleep(10 * time.Millisecond) // Let GetOrLoad join the batch
+	cancel()
+	if err := <-batchErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled GetOrLoadMulti = %v, want context.Canceled", err)
+	}
+	close(release)
+	if err := <-joined; err != nil {
+		t.Errorf("GetOrLoad joining the batch = %v after its caller cancelled", err)
+	}
+	for _, key := range []string{"a", "b"} {
+		if !c.Has([]byte(key)) {
+			t.Errorf("batch value of %s was not cached", key)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// LimitPolicy decides what happens to operations over a namespace's rate limit
+type LimitPolicy int
+
+const (
+	// RejectOverLimit fails operations over the rate limit with ErrRateLimited
+	RejectOverLimit LimitPolicy = iota
+	// QueueOverLimit delays operations over the rate limit until they fit, up to MaxWait
+	QueueOverLimit
+)
+
+// NamespaceOpts contains the limits applied to a single namespace
+type NamespaceOpts struct {
+	RateLimit float64       // Get and Put operations per second, 0 is unlimited
+	Burst     int           // Operations allowed in a burst above RateLimit, at least 1
+	OverLimit LimitPolicy   // What to do with operations over RateLimit
+	MaxWait   time.Duration // Longest an operation is queued before being rejected, 0 waits as long as needed
+
+	// MaxBytes is the byte quota of the namespace's keys' values. Writes over
+	// the quota evict the namespace's own least recently used entries, so a
+	// tenant at its quota never pushes out another tenant's data.
+	MaxBytes int64
+
+	MaxConcurrentLoads int // Loaders GetOrLoad runs at once for the namespace, 0 is unlimited
+
+	Transform Transformer // Replaces the cache's Transform for the namespace's values
+
+	// TTL replaces the cache's TTL for the namespace's keys, 0 keeps it. With
+	// SlidingTTL reads restart it, so e.g. sessions expire after TTL idle
+	// rather than TTL after they were written.
+	TTL        time.Duration
+	SlidingTTL bool
+}
+
+// tokenBucket is the rate limiter state of a namespace
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NamespaceBytes returns the number of bytes stored in a namespace
+func (c *Cache) NamespaceBytes(namespace string) int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.nsBytes[namespace]
+}
+
+// namespaceOf returns the namespace of a key, including internal chunk keys
+func (c *Cache) namespaceOf(key string) string {
+	sep := c.CacheOpts.NamespaceSeparator
+	if sep == "" {
+		return ""
+	}
+	ns, _, found := strings.Cut(strings.TrimPrefix(key, chunkPrefix), sep)
+	if !found {
+		return ""
+	}
+	return ns
+}
+
+// limit applies the rate limit of the key's namespace to one operation.
+// Waiting for a queued operation's turn stops with ctx.
+func (c *Cache) limit(ctx context.Context, key []byte) error {
+	if len(c.CacheOpts.Namespaces) == 0 {
+		return nil
+	}
+	ns := c.namespaceOf(string(key))
+	opts := c.CacheOpts.Namespaces[ns]
+	if opts.RateLimit <= 0 {
+		return nil
+	}
+	wait, ok := c.takeToken(ns, opts)
+	if !ok {
+		return ErrRateLimited
+	}
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		c.returnToken(ns) // Let the next operation have the turn
+		return ctx.Err()
+	}
+}
+
+// returnToken gives back a token reserved by an operation that gave up
+func (c *Cache) returnToken(ns string) {
+	c.limitMu.Lock()
+	defer c.limitMu.Unlock()
+	if b, ok := c.buckets[ns]; ok {
+		b.tokens++
+	}
+}
+
+// takeToken takes a token from the namespace's bucket. When queueing, it
+// reserves a future token and returns how long to wait for it.
+func (c *Cache) takeToken(ns string, opts NamespaceOpts) (time.Duration, bool) {
+	c.limitMu.Lock()
+	defer c.limitMu.Unlock()
+
+	burst := float64(opts.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	now := time.Now()
+	b, ok := c.buckets[ns]
+	if !ok {
+		b = &tokenBucket{tokens: burst, last: now}
+		c.buckets[ns] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * opts.RateLimit
+	if b.tokens > burst {
+		b.tokens = burst
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	if opts.OverLimit != QueueOverLimit {
+		return 0, false
+	}
+	wait := time.Duration((1 - b.tokens) / opts.RateLimit * float64(time.Second))
+	if opts.MaxWait > 0 && wait > opts.MaxWait {
+		return 0, false
+	}
+	b.tokens--
+	return wait, true
+}
+
+// enforceQuota evicts the least recently used entries of the key's namespace
+// until a value of the given size fits in the namespace's byte quota
+func (c *Cache) enforceQuota(key string, size int) error {
+	ns := c.namespaceOf(key)
+	quota := c.CacheOpts.Namespaces[ns].MaxBytes
+	if quota <= 0 {
+		return nil
+	}
+	if int64(size) > quota {
+		return ErrQuotaExceeded
+	}
+	for c.nsBytes[ns]-c.sizeOf(key)+int64(size) > quota {
+		victim := c.oldestIn(ns, key)
+		if victim == "" {
+			break
+		}
+		c.trace(victim, "evict", "quota")
+		c.remove(victim)
+		c.evictions++
+	}
+	return nil
+}
+
+// slide restarts the TTL of a read key whose namespace has SlidingTTL
+func (c *Cache) slide(key string) {
+	if len(c.CacheOpts.Namespaces) == 0 || !c.CacheOpts.Namespaces[c.namespaceOf(key)].SlidingTTL {
+		return
+	}
+	c.setWritten(key, c.now())
+}
+
+// sizeOf returns the bytes stored for a key, including its chunks
+func (c *Cache) sizeOf(key string) int64 {
+	size := int64(len(c.stored(key)))
+	for _, chunkKey := range c.chunks[key] {
+		size += int64(len(c.stored(chunkKey)))
+	}
+	return size
+}
+
+// oldestIn returns the next key of a namespace to evict other than exclude
+func (c *Cache) oldestIn(ns, exclude string) string {
+	victim := ""
+	c.policy.Iterate(func(k string) bool {
+		if owner, ok := c.chunkOwner[k]; ok {
+			k = owner
+		}
+		if k != exclude && c.namespaceOf(k) == ns {
+			victim = k
+			return false
+		}
+		return true
+	})
+	return victim
+}
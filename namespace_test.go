@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNamespaceRateLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		ns      NamespaceOpts
+		timeout time.Duration // Of the over-limit operation's context, 0 for none
+		wantErr error
+		minWait time.Duration
+	}{
+		{name: "reject", ns: NamespaceOpts{RateLimit: 1, OverLimit: RejectOverLimit}, wantErr: ErrRateLimited},
+		{name: "queue", ns: NamespaceOpts{RateLimit: 20, OverLimit: QueueOverLimit}, minWait: 25 * time.Millisecond},
+		{name: "queue past MaxWait", ns: NamespaceOpts{RateLimit: 1, OverLimit: QueueOverLimit, MaxWait: time.Millisecond}, wantErr: ErrRateLimited},
+		{name: "queue cancelled", ns: NamespaceOpts{RateLimit: 0.01, OverLimit: QueueOverLimit}, timeout: 20 * time.Millisecond, wantErr: context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(CacheOpts{Capacity: 10, NamespaceSeparator: ":", Namespaces: map[string]NamespaceOpts{"tenant": tt.ns}})
+			defer c.Close(context.Background())
+			key := []byte("tenant:k")
+			if err := c.Put(key, []byte("v")); err != nil {
+				t.Fatalf("first Put: %v", err)
+			}
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			start := time.Now()
+			_, err := c.GetContext(ctx, key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("over-limit Get = %v, want %v", err, tt.wantErr)
+			}
+			if took := time.Since(start); took < tt.minWait || took > time.Second {
+				t.Errorf("over-limit Get took %v, want at least %v and well under a second", took, tt.minWait)
+			}
+			if _, err := c.GetContext(context.Background(), []byte("other:k")); !isMiss(err) {
+				t.Errorf("Get in an unlimited namespace = %v, want a miss", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+const defaultExpireQueueLimit = 10000
+
+// expiration is an OnExpire notification waiting to be delivered
+type expiration struct {
+	key   string
+	value []byte
+}
+
+// queueExpiration queues the notification of an expired item, with the
+// cache locked
+func (c *Cache) queueExpiration(key string) {
+	if c.CacheOpts.OnExpire == nil {
+		return
+	}
+	if owner, ok := c.chunkOwner[key]; ok {
+		key = owner
+	}
+	if _, found := c.items.Get(key); !found {
+		return
+	}
+	c.expiredMu.Lock()
+	c.expiredQueue = append(c.expiredQueue, expiration{key: key, value: c.plain(key, c.valueOf(key))})
+	c.trimExpirations()
+	c.expiredMu.Unlock()
+	select {
+	case c.expiredSignal <- struct{}{}:
+	default:
+	}
+}
+
+// notifyLoop delivers queued expirations to OnExpire, retrying those that
+// failed every ExpireRetry. It makes a last attempt when the cache closes.
+func (c *Cache) notifyLoop(done <-chan struct{}) {
+	retry := c.CacheOpts.ExpireRetry
+	if retry <= 0 {
+		retry = time.Second
+	}
+	ticker := time.NewTicker(retry)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			c.notifyExpirations()
+			return
+		case <-c.expiredSignal:
+		case <-ticker.C:
+		}
+		c.notifyExpirations()
+	}
+}
+
+// notifyExpirations delivers every queued expiration once, requeueing the
+// ones OnExpire failed or panicked on
+func (c *Cache) notifyExpirations() {
+	c.expiredMu.Lock()
+	queue := c.expiredQueue
+	c.expiredQueue = nil
+	c.expiredMu.Unlock()
+
+	var failed []expiration
+	for _, e := range queue {
+		delivered := false
+		c.callback("OnExpire", func() {
+			if err := c.CacheOpts.OnExpire(e.key, e.value); err != nil {
+				c.reportError(fmt.Errorf("notifying expiration of %q: %w", c.displayKey([]byte(e.key)), err))
+				return
+			}
+			delivered = true
+		})
+		if !delivered {
+			failed = append(failed, e)
+		}
+	}
+	if len(failed) > 0 {
+		c.expiredMu.Lock()
+		c.expiredQueue = slices.Concat(failed, c.expiredQueue) // Keep expiration order
+		c.trimExpirations()
+		c.expiredMu.Unlock()
+	}
+}
+
+// trimExpirations drops the oldest queued expirations past
+// ExpireQueueLimit, with expiredMu held
+func (c *Cache) trimExpirations() {
+	limit := c.CacheOpts.ExpireQueueLimit
+	if limit <= 0 {
+		limit = defaultExpireQueueLimit
+	}
+	if n := len(c.expiredQueue) - limit; n > 0 {
+		c.expiredQueue = c.expiredQueue[n:]
+		c.expiredDropped += n
+	}
+}
+
+// PendingExpirations returns the keys of expired items whose OnExpire
+// notification was not delivered yet, e.g. to record them before exiting
+func (c *Cache) PendingExpirations() []string {
+	c.expiredMu.Lock()
+	defer c.expiredMu.Unlock()
+
+	keys := make([]string, len(c.expiredQueue))
+	for i, e := range c.expiredQueue {
+		keys[i] = e.key
+	}
+	return keys
+}
@@ -0,0 +1,34 @@
+package cache
+
+// LRUOrder returns the stored keys from the least to the most recently used,
+// which is exactly the order in which they will be evicted. It is meant for
+// tests and debugging. With a custom Policy it returns the policy's eviction
+// order instead. With the default policy the order is deterministic:
+//
+//   - Put, Get, GetReader and Upsert make an item the most recently used;
+//     overwriting an item counts as a fresh insert. PromoteEvery and
+//     PromoteInterval skip the promotion of some reads.
+//   - Has leaves the order untouched unless HasPromotes is set.
+//   - Operations are ordered by when they take the cache lock, so items
+//     written within the same clock tick never tie.
+//   - Eviction removes the front of the order. A chunked value is removed as
+//     a whole as soon as any of its chunks reaches the front.
+//   - Namespace quotas evict the namespace's items in the same order.
+//
+// Expired items keep their position until they are removed.
+func (c *Cache) LRUOrder() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return nil
+	}
+	keys := make([]string, 0, c.items.Len()-len(c.chunkOwner))
+	c.policy.Iterate(func(key string) bool {
+		if _, isChunk := c.chunkOwner[key]; !isChunk {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	return keys
+}
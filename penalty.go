@@ -0,0 +1,73 @@
+package cache
+
+import "time"
+
+// MissPenalty is what misses cost in loaders and what hits saved
+type MissPenalty struct {
+	Loads    int           // Loader calls by GetOrLoad
+	LoadTime time.Duration // Time spent in those loaders
+	Hits     int
+}
+
+// AvgLoadTime returns the mean time a loader took, the penalty of a miss
+func (p MissPenalty) AvgLoadTime() time.Duration {
+	if p.Loads == 0 {
+		return 0
+	}
+	return p.LoadTime / time.Duration(p.Loads)
+}
+
+// Saved estimates the loader time the hits saved, assuming each would
+// otherwise have taken the average load time
+func (p MissPenalty) Saved() time.Duration {
+	return time.Duration(p.Hits) * p.AvgLoadTime()
+}
+
+// MissPenalties returns the miss penalty of every namespace under
+// TrackMissPenalty
+func (c *Cache) MissPenalties() map[string]MissPenalty {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	penalties := make(map[string]MissPenalty, len(c.penalties))
+	for ns, p := range c.penalties {
+		penalties[ns] = *p
+	}
+	return penalties
+}
+
+// LatencySaved estimates the loader time hits saved across namespaces under
+// TrackMissPenalty, weighting each namespace by its own average load time
+func (c *Cache) LatencySaved() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var saved time.Duration
+	for _, p := range c.penalties {
+		saved += p.Saved()
+	}
+	return saved
+}
+
+// penaltyOf returns the miss penalty counters of a key's namespace
+func (c *Cache) penaltyOf(key string) *MissPenalty {
+	ns := c.namespaceOf(key)
+	p, ok := c.penalties[ns]
+	if !ok {
+		p = &MissPenalty{}
+		c.penalties[ns] = p
+	}
+	return p
+}
+
+// recordLoad adds a loader call to the miss penalty of a key's namespace
+func (c *Cache) recordLoad(key string, took time.Duration) {
+	if !c.CacheOpts.TrackMissPenalty {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p := c.penaltyOf(key)
+	p.Loads++
+	p.LoadTime += took
+}
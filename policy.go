@@ -0,0 +1,134 @@
+package cache
+
+// Policy decides the order in which entries are evicted. The cache calls it
+// under its own lock, so implementations need not be safe for concurrent
+// use. The internal chunk keys of chunked values are tracked like any other
+// key; evicting a chunk evicts its whole value.
+type Policy interface {
+	// RecordInsert starts tracking a newly stored key
+	RecordInsert(key string)
+	// RecordAccess notes a read of a tracked key
+	RecordAccess(key string)
+	// Remove stops tracking a key
+	Remove(key string)
+	// Victim returns the key to evict next, false if no key is tracked
+	Victim() (string, bool)
+	// Iterate calls fn for every tracked key in eviction order, the next
+	// victim first, until fn returns false. fn does not modify the policy.
+	Iterate(fn func(key string) bool)
+}
+
+// maxFreeNodes bounds the nodes an LRU policy keeps for reuse
+const maxFreeNodes = 1024
+
+// lruNode is an element of the LRU list
+type lruNode struct {
+	key        string
+	prev, next *lruNode
+}
+
+// lruPolicy is the default Policy, evicting the least recently used key
+type lruPolicy struct {
+	root  lruNode // Sentinel: root.next is the least, root.prev the most recently used
+	nodes map[string]*lruNode
+
+	// Removed nodes are reused by later inserts, so steady insert and evict
+	// churn does not allocate
+	free  *lruNode // Linked through next
+	nfree int
+}
+
+// NewLRUPolicy creates the default least recently used Policy
+func NewLRUPolicy() Policy {
+	p := &lruPolicy{nodes: make(map[string]*lruNode)}
+	p.root.prev, p.root.next = &p.root, &p.root
+	return p
+}
+
+func (p *lruPolicy) RecordInsert(key string) {
+	if n, ok := p.nodes[key]; ok {
+		p.moveToBack(n)
+		return
+	}
+	n := p.free
+	if n != nil {
+		p.free, n.next = n.next, nil
+		p.nfree--
+	} else {
+		n = &lruNode{}
+	}
+	n.key = key
+	p.nodes[key] = n
+	p.pushBack(n)
+}
+
+func (p *lruPolicy) RecordAccess(key string) {
+	if n, ok := p.nodes[key]; ok {
+		p.moveToBack(n)
+	}
+}
+
+func (p *lruPolicy) Remove(key string) {
+	if n, ok := p.nodes[key]; ok {
+		p.unlink(n)
+		delete(p.nodes, key)
+		if p.nfree < maxFreeNodes {
+			n.key, n.next = "", p.free
+			p.free = n
+			p.nfree++
+		}
+	}
+}
+
+func (p *lruPolicy) Victim() (string, bool) {
+	if p.root.next == &p.root {
+		return "", false
+	}
+	return p.root.next.key, true
+}
+
+func (p *lruPolicy) Iterate(fn func(key string) bool) {
+	for n := p.root.next; n != &p.root; n = n.next {
+		if !fn(n.key) {
+			return
+		}
+	}
+}
+
+// pushBack appends a node as the most recently used
+func (p *lruPolicy) pushBack(n *lruNode) {
+	n.prev, n.next = p.root.prev, &p.root
+	n.prev.next, p.root.prev = n, n
+}
+
+// unlink takes a node out of the list
+func (p *lruPolicy) unlink(n *lruNode) {
+	n.prev.next, n.next.prev = n.next, n.prev
+	n.prev, n.next = nil, nil
+}
+
+// moveToBack makes a node the most recently used
+func (p *lruPolicy) moveToBack(n *lruNode) {
+	p.unlink(n)
+	p.pushBack(n)
+}
+
+// SetPolicy replaces the eviction policy of a running cache, e.g. to try
+// another policy in production without dropping the cache. p must be new:
+// the stored keys are inserted into it in the current eviction order, so
+// their recency carries over but state such as access frequencies does not.
+// Clones keep creating their policy with NewPolicy.
+func (c *Cache) SetPolicy(p Policy) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	c.policy.Iterate(func(key string) bool {
+		p.RecordInsert(key)
+		return true
+	})
+	c.policy = p
+	return nil
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"slices"
+	"time"
+)
+
+// defaultPressureWindow is the eviction rate window used when PressureWindow is unset
+const defaultPressureWindow = 10 * time.Second
+
+// Pressure describes how hard a cache is working to make room for new items
+type Pressure struct {
+	EvictionRate float64 // Evictions per second over the last full PressureWindow
+	Usage        float64 // Fraction of Capacity or MaxBytes in use, whichever is higher
+}
+
+// PressurePolicy decides whether a Put inserting a new key into a namespace
+// should be dropped instead of evicting other items
+type PressurePolicy interface {
+	Shed(namespace string, p Pressure) bool
+}
+
+// ThresholdShedding is a PressurePolicy that sheds inserts into its
+// low-priority namespaces while eviction churn or usage is above a threshold
+type ThresholdShedding struct {
+	LowPriority     []string
+	MaxEvictionRate float64 // Evictions per second, 0 ignores churn
+	MaxUsage        float64 // Fraction of capacity, 0 ignores usage
+}
+
+// Shed implements PressurePolicy
+func (t ThresholdShedding) Shed(namespace string, p Pressure) bool {
+	if !slices.Contains(t.LowPriority, namespace) {
+		return false
+	}
+	return (t.MaxEvictionRate > 0 && p.EvictionRate > t.MaxEvictionRate) ||
+		(t.MaxUsage > 0 && p.Usage > t.MaxUsage)
+}
+
+// shouldShed consults the pressure policy about inserting a new key
+func (c *Cache) shouldShed(strKey string) bool {
+	if c.CacheOpts.PressurePolicy == nil {
+		return false
+	}
+	if _, found := c.items.Get(strKey); found {
+		return false // Dropping an update would leave a stale value behind
+	}
+	return c.CacheOpts.PressurePolicy.Shed(c.namespaceOf(strKey), c.pressure())
+}
+
+// pressure returns the current pressure, rolling the eviction rate window
+func (c *Cache) pressure() Pressure {
+	window := c.CacheOpts.PressureWindow
+	if window <= 0 {
+		window = defaultPressureWindow
+	}
+	now := c.now()
+	if c.windowStart.IsZero() {
+		c.windowStart, c.windowEvictions = now, c.evictions
+	} else if elapsed := now.Sub(c.windowStart); elapsed >= window {
+		c.evictionRate = float64(c.evictions-c.windowEvictions) / elapsed.Seconds()
+		c.windowStart, c.windowEvictions = now, c.evictions
+	}
+
+	p := Pressure{EvictionRate: c.evictionRate}
+	if c.CacheOpts.Capacity > 0 {
+		p.Usage = float64(c.items.Len()) / float64(c.CacheOpts.Capacity)
+	}
+	if c.CacheOpts.MaxBytes > 0 {
+		p.Usage = max(p.Usage, float64(c.bytes.Load())/float64(c.CacheOpts.MaxBytes))
+	}
+	return p
+}
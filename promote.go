@@ -0,0 +1,30 @@
+package cache
+
+import "time"
+
+// promotion tracks the accesses of an item since it was last promoted
+type promotion struct {
+	accesses int
+	at       time.Time // Zero until the first promotion after the write
+}
+
+// promote reports whether an access to an item should be recorded with the
+// eviction policy under PromoteEvery and PromoteInterval
+func (c *Cache) promote(key string) bool {
+	every, interval := c.CacheOpts.PromoteEvery, c.CacheOpts.PromoteInterval
+	if every <= 0 && interval <= 0 {
+		return true
+	}
+	p := c.promotions[key]
+	if p.at.IsZero() {
+		p.at = c.timestamps[key] // Writing an item promotes it
+	}
+	p.accesses++
+	now := c.now()
+	if (every > 0 && p.accesses >= every) || (interval > 0 && now.Sub(p.at) >= interval) {
+		c.promotions[key] = promotion{at: now}
+		return true
+	}
+	c.promotions[key] = p
+	return false
+}
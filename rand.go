@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"hash/maphash"
+	"math/rand"
+)
+
+// RandSource is the randomness a cache draws on, for the doorkeeper's
+// admission decisions and to seed the hashes of its filters. *rand.Rand
+// implements it. It is called with the cache locked, so it only needs to
+// be safe for concurrent use when shared by several caches, as clones do.
+type RandSource interface {
+	Float64() float64
+	Uint64() uint64
+}
+
+// newRand returns the randomness of a cache: opts.Rand, a source seeded
+// with opts.Seed, or nil for the global source
+func newRand(opts CacheOpts) RandSource {
+	if opts.Rand != nil {
+		return opts.Rand
+	}
+	if opts.Seed != 0 {
+		return rand.New(rand.NewSource(opts.Seed))
+	}
+	return nil
+}
+
+// float64 returns a random number in [0, 1)
+func (c *Cache) float64() float64 {
+	if c.rng == nil {
+		return rand.Float64()
+	}
+	return c.rng.Float64()
+}
+
+// newHash returns a string hash for the doorkeeper and frequency sketch,
+// seeded from the cache's RandSource so runs with the same seed hash alike.
+// Without one it is maphash with a random seed.
+func (c *Cache) newHash() func(key string) uint64 {
+	if c.rng == nil {
+		seed := maphash.MakeSeed()
+		return func(key string) uint64 { return maphash.String(seed, key) }
+	}
+	seed := c.rng.Uint64()
+	return func(key string) uint64 { return seededHash(seed, key) }
+}
+
+// seededHash is FNV-1a of key starting from seed, with its high bits folded
+// in since the filters split the hash into two halves
+func seededHash(seed uint64, key string) uint64 {
+	h := seed ^ 14695981039346656037
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= 1099511628211
+	}
+	return h ^ h>>29
+}
@@ -0,0 +1,27 @@
+package cache
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HMACKeyRedactor returns a KeyRedactor replacing keys with the first 16 hex
+// digits of their HMAC-SHA256 under secret, so the same key always shows up
+// the same way without revealing it
+func HMACKeyRedactor(secret []byte) func([]byte) string {
+	return func(key []byte) string {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(key)
+		return hex.EncodeToString(mac.Sum(nil)[:8])
+	}
+}
+
+// displayKey returns a key as it may be shown in audit records and other
+// diagnostics
+func (c *Cache) displayKey(key []byte) string {
+	if c.CacheOpts.KeyRedactor != nil {
+		return c.CacheOpts.KeyRedactor(key)
+	}
+	return string(key)
+}
@@ -0,0 +1,46 @@
+package cache
+
+// RequestCache is a per-request view of a shared cache that remembers every
+// read, hits and misses alike, so repeated reads of a key within one request
+// reach the shared cache once and see the same result. Nothing is written
+// back to the shared cache. A RequestCache is not safe for concurrent use and
+// is meant to be dropped at the end of the request.
+type RequestCache struct {
+	shared Cacher
+	reads  map[string]requestRead
+}
+
+// requestRead is the remembered result of a read
+type requestRead struct {
+	value []byte
+	err   error
+}
+
+// NewRequestCache creates a request-scoped view of shared
+func NewRequestCache(shared Cacher) *RequestCache {
+	return &RequestCache{shared: shared, reads: make(map[string]requestRead)}
+}
+
+// Get retrieves an item, reading the shared cache only the first time the
+// key is requested. Errors other than misses are not remembered.
+func (r *RequestCache) Get(key []byte) ([]byte, error) {
+	if read, ok := r.reads[string(key)]; ok {
+		return read.value, read.err
+	}
+	value, err := r.shared.Get(key)
+	if err == nil || isMiss(err) {
+		r.reads[string(key)] = requestRead{value: value, err: err}
+	}
+	return value, err
+}
+
+// Has checks if a key exists, remembering the read like Get
+func (r *RequestCache) Has(key []byte) bool {
+	_, err := r.Get(key)
+	return err == nil
+}
+
+// Forget drops the remembered read of a key, e.g. after the request wrote it
+func (r *RequestCache) Forget(key []byte) {
+	delete(r.reads, string(key))
+}
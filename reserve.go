@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Reservation is the token of a key reserved with Reserve
+type Reservation uint64
+
+// reservation is a placeholder on a key
+type reservation struct {
+	token   Reservation
+	expires time.Time
+}
+
+// Reserve places a placeholder on key so that only the holder of the
+// returned token can fill it, e.g. while it computes an expensive value.
+// Until the holder calls PutReserved or Release, or ttl elapses, other
+// writes of the key fail with ErrReserved. Reads are not affected. Reserving
+// a key that is already reserved fails with ErrReserved.
+func (c *Cache) Reserve(key []byte, ttl time.Duration) (Reservation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return 0, ErrClosed
+	}
+	strKey := c.storageKey(key)
+	if err := c.checkReservation(strKey, 0); err != nil {
+		return 0, err
+	}
+	c.lastToken++
+	c.reservations[strKey] = reservation{token: c.lastToken, expires: c.now().Add(ttl)}
+	return c.lastToken, nil
+}
+
+// PutReserved fills a reserved key with the cache TTL and ends the
+// reservation. It fails with ErrReserved if the reservation expired and
+// another caller reserved the key since. A write that stores nothing, e.g.
+// because it is shed or not admitted, keeps the reservation.
+func (c *Cache) PutReserved(key, value []byte, token Reservation) error {
+	return c.write(context.Background(), key, value, writeOpts{token: token})
+}
+
+// Release ends a reservation without filling the key and reports whether
+// the token still held it
+func (c *Cache) Release(key []byte, token Reservation) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false
+	}
+	strKey := c.storageKey(key)
+	if r, ok := c.reservations[strKey]; ok && r.token == token {
+		delete(c.reservations, strKey)
+		return true
+	}
+	return false
+}
+
+// checkReservation fails writes to a key reserved for another token
+func (c *Cache) checkReservation(key string, token Reservation) error {
+	r, ok := c.reservations[key]
+	if !ok {
+		return nil
+	}
+	if c.now().After(r.expires) {
+		delete(c.reservations, key)
+		return nil
+	}
+	if r.token != token {
+		return ErrReserved
+	}
+	return nil
+}
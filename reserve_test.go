@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReservations(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       CacheOpts
+		ttl        time.Duration
+		wait       time.Duration
+		fillErr    error // Of the holder's PutReserved
+		stored     bool  // Whether PutReserved stored the value
+		putErr     error // Of another caller's Put afterwards
+		releasable bool  // Whether the holder still holds the reservation
+	}{
+		{name: "filled", opts: CacheOpts{Capacity: 10}, ttl: time.Hour, stored: true},
+		{name: "expired", opts: CacheOpts{Capacity: 10}, ttl: time.Millisecond, wait: 5 * time.Millisecond, stored: true},
+		{name: "not admitted", opts: CacheOpts{Capacity: 10, DoorkeeperRejectRate: 1}, ttl: time.Hour, putErr: ErrReserved, releasable: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(tt.opts)
+			defer c.Close(context.Background())
+			key := []byte("k")
+
+			token, err := c.Reserve(key, tt.ttl)
+			if err != nil {
+				t.Fatalf("Reserve: %v", err)
+			}
+			if _, err := c.Reserve(key, tt.ttl); !errors.Is(err, ErrReserved) {
+				t.Fatalf("second Reserve = %v, want ErrReserved", err)
+			}
+			if err := c.Put(key, []byte("other")); !errors.Is(err, ErrReserved) {
+				t.Fatalf("Put of a reserved key = %v, want ErrReserved", err)
+			}
+			time.Sleep(tt.wait)
+
+			if err := c.PutReserved(key, []byte("v"), token); !errors.Is(err, tt.fillErr) {
+				t.Fatalf("PutReserved = %v, want %v", err, tt.fillErr)
+			}
+			if got := c.Has(key); got != tt.stored {
+				t.Errorf("Has after PutReserved = %v, want %v", got, tt.stored)
+			}
+			if err := c.Put(key, []byte("other")); !errors.Is(err, tt.putErr) {
+				t.Errorf("Put after PutReserved = %v, want %v", err, tt.putErr)
+			}
+			if got := c.Release(key, token); got != tt.releasable {
+				t.Errorf("Release = %v, want %v", got, tt.releasable)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package cache
+
+import "hash/maphash"
+
+const (
+	scanMinBits    = 4  // Buckets of a new scan index, as a power of two
+	scanLoadFactor = 4  // Keys per bucket above which the scan index doubles
+	scanVisitLimit = 10 // Buckets a Scan call may visit per key requested
+)
+
+// Scan incrementally enumerates the keys of the cache, Redis SCAN style.
+// Start with cursor 0 and pass the returned cursor to the next call until it
+// is 0 again. Each call returns up to about count keys matching the Redis
+// glob pattern match ("" or "*" matches every key; unlike path.Match, '*'
+// also matches '/'). Like Redis SCAN it may return fewer keys, or none, with
+// a non-zero cursor: a call visits at most 10*count hash buckets of about
+// four keys each, however few of their keys match, so long scans never block
+// writers for long. Keys present for the whole scan are returned exactly
+// once; keys written or removed during it may or may not be. Keys are
+// returned as stored, i.e. as digests when HashKeys is set. The first Scan
+// indexes the cache's keys, which are then kept indexed as they are written
+// and removed.
+func (c *Cache) Scan(cursor uint64, match string, count int) (keys [][]byte, next uint64) {
+	if count <= 0 {
+		count = 10
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, 0
+	}
+	if c.scanIndex == nil {
+		c.scanIndex = newScanIndex(c.keySeed)
+		c.items.Iterate(func(key string, _ []byte) bool {
+			if _, isChunk := c.chunkOwner[key]; !isChunk {
+				c.scanIndex.add(key)
+			}
+			return true
+		})
+	}
+
+	// The cursor is the lowest hash not visited yet. Buckets hold ranges of
+	// hashes and only ever split, so a cursor stays on a bucket boundary.
+	idx := c.scanIndex
+	b, visited := idx.bucketOf(cursor), 0
+	for ; b < len(idx.buckets) && len(keys) < count && visited < scanVisitLimit*count; b++ {
+		visited++
+		for key := range idx.buckets[b] {
+			if c.expired(key) || (match != "" && match != "*" && !globMatch(match, key)) {
+				continue
+			}
+			keys = append(keys, []byte(key))
+		}
+	}
+	if b < len(idx.buckets) {
+		next = uint64(b) << (64 - idx.bits)
+	}
+	return keys, next
+}
+
+// scanIndex groups keys into buckets by the top bits of their hash, so
+// Scan can resume from a cursor without visiting every key
+type scanIndex struct {
+	seed    maphash.Seed
+	bits    int
+	buckets []keySet
+	size    int
+}
+
+// newScanIndex creates an empty scan index
+func newScanIndex(seed maphash.Seed) *scanIndex {
+	idx := &scanIndex{seed: seed, bits: scanMinBits, buckets: make([]keySet, 1<<scanMinBits)}
+	for i := range idx.buckets {
+		idx.buckets[i] = make(keySet)
+	}
+	return idx
+}
+
+// bucketOf returns the bucket of a hash
+func (idx *scanIndex) bucketOf(h uint64) int {
+	return int(h >> (64 - idx.bits))
+}
+
+// add indexes a key, doubling the buckets when they fill up
+func (idx *scanIndex) add(key string) {
+	bucket := idx.buckets[idx.bucketOf(maphash.String(idx.seed, key))]
+	if _, ok := bucket[key]; ok {
+		return
+	}
+	bucket[key] = struct{}{}
+	idx.size++
+	if idx.size > scanLoadFactor*len(idx.buckets) {
+		idx.grow()
+	}
+}
+
+// remove stops indexing a key
+func (idx *scanIndex) remove(key string) {
+	bucket := idx.buckets[idx.bucketOf(maphash.String(idx.seed, key))]
+	if _, ok := bucket[key]; ok {
+		delete(bucket, key)
+		idx.size--
+	}
+}
+
+// grow splits every bucket in two. The index never shrinks, since merging
+// buckets would move cursors back and repeat keys.
+func (idx *scanIndex) grow() {
+	old := idx.buckets
+	idx.bits++
+	idx.buckets = make([]keySet, 2*len(old))
+	for i := range idx.buckets {
+		idx.buckets[i] = make(keySet, scanLoadFactor)
+	}
+	for _, bucket := range old {
+		for key := range bucket {
+			idx.buckets[idx.bucketOf(maphash.String(idx.seed, key))][key] = struct{}{}
+		}
+	}
+}
+
+// globMatch reports whether s matches a Redis glob pattern. Unlike
+// path.Match, '*' also matches '/', and malformed patterns are matched
+// leniently rather than rejected: '?' matches any byte, '*' any run of
+// bytes, '[abc]', '[a-z]' and '[^a]' match a byte in or not in a class, and
+// '\' matches the next pattern byte literally.
+func globMatch(pattern, s string) bool {
+	px, sx := 0, 0
+	starPx, starSx := -1, 0 // Where to resume after the last '*' on a mismatch
+	for px < len(pattern) || sx < len(s) {
+		if px < len(pattern) {
+			switch p := pattern[px]; {
+			case p == '*':
+				starPx, starSx = px, sx+1
+				px++
+				continue
+			case sx == len(s):
+			case p == '?':
+				px++
+				sx++
+				continue
+			case p == '[':
+				if ok, end := matchClass(pattern, px, s[sx]); ok {
+					px, sx = end, sx+1
+					continue
+				}
+			case p == '\\' && px+1 < len(pattern):
+				if s[sx] == pattern[px+1] {
+					px, sx = px+2, sx+1
+					continue
+				}
+			case s[sx] == p:
+				px++
+				sx++
+				continue
+			}
+		}
+		if starPx < 0 || starSx > len(s) {
+			return false
+		}
+		px, sx = starPx, starSx
+	}
+	return true
+}
+
+// matchClass matches b against the character class starting at
+// pattern[px], returning whether it matched and where the class ends
+func matchClass(pattern string, px int, b byte) (matched bool, end int) {
+	i := px + 1
+	negate := i < len(pattern) && pattern[i] == '^'
+	if negate {
+		i++
+	}
+	for ; i < len(pattern) && pattern[i] != ']'; i++ {
+		switch {
+		case pattern[i] == '\\' && i+1 < len(pattern):
+			i++
+			matched = matched || pattern[i] == b
+		case i+2 < len(pattern) && pattern[i+1] == '-' && pattern[i+2] != ']':
+			lo, hi := min(pattern[i], pattern[i+2]), max(pattern[i], pattern[i+2])
+			matched = matched || (lo <= b && b <= hi)
+			i += 2
+		default:
+			matched = matched || pattern[i] == b
+		}
+	}
+	return matched != negate, min(i+1, len(pattern))
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestScanReturnsMatchingKeysOnce(t *testing.T) {
+	c := NewCache(CacheOpts{Capacity: 1000})
+	defer c.Close(context.Background())
+	for i := 0; i < 300; i++ {
+		c.Put([]byte(fmt.Sprintf("user:%d", i)), []byte("v"))
+		c.Put([]byte(fmt.Sprintf("order/%d", i)), []byte("v"))
+	}
+
+	tests := []struct {
+		match string
+		want  int
+	}{
+		{"", 600},
+		{"*", 600},
+		{"user:*", 300},
+		{"order/*", 300},
+		{"user:1?", 10},
+		{"user:[12]", 2},
+		{"user:[^0-9]*", 0},
+		{"none*", 0},
+	}
+	for _, tt := range tests {
+		seen := make(map[string]int)
+		cursor := uint64(0)
+		for {
+			keys, next := c.Scan(cursor, tt.match, 7)
+			for _, key := range keys {
+				seen[string(key)]++
+			}
+			if next == 0 {
+				break
+			}
+			cursor = next
+		}
+		if len(seen) != tt.want {
+			t.Errorf("Scan %q found %d keys, want %d", tt.match, len(seen), tt.want)
+		}
+		for key, n := range seen {
+			if n != 1 {
+				t.Errorf("Scan %q returned %q %d times", tt.match, key, n)
+			}
+		}
+	}
+}
+
+func TestScanBoundsBucketsVisited(t *testing.T) {
+	c := NewCache(CacheOpts{Capacity: 100000})
+	defer c.Close(context.Background())
+	for i := 0; i < 20000; i++ {
+		c.Put([]byte(fmt.Sprintf("k%d", i)), []byte("v"))
+	}
+
+	for _, count := range []int{1, 10, 100} {
+		calls, cursor := 0, uint64(0)
+		for {
+			keys, next := c.Scan(cursor, "no-match-*", count)
+			calls++
+			if len(keys) != 0 {
+				t.Fatalf("count %d: Scan returned %d keys for a pattern matching none", count, len(keys))
+			}
+			if next == 0 {
+				break
+			}
+			bits := c.scanIndex.bits
+			if visited := c.scanIndex.bucketOf(next) - c.scanIndex.bucketOf(cursor); visited > scanVisitLimit*count || visited <= 0 {
+				t.Fatalf("count %d: Scan visited %d of %d buckets in one call", count, visited, 1<<bits)
+			}
+			cursor = next
+		}
+		if want := len(c.scanIndex.buckets) / (scanVisitLimit * count); calls < want {
+			t.Errorf("count %d: scan took %d calls, want at least %d", count, calls, want)
+		}
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*", "a/b", true},
+		{"user:*", "user:1/2", true},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-b]llo", "hbllo", true},
+		{`h\*`, "h*", true},
+		{`h\*`, "hx", false},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+		{"", "", true},
+		{"a[", "a", false},
+	}
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
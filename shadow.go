@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ShadowStats compares the hit ratios of the caches behind a ShadowCache
+type ShadowStats struct {
+	Reads       int64
+	PrimaryHits int64
+	ShadowHits  int64
+	PrimaryOnly int64 // Reads that hit the primary but missed the shadow
+	ShadowOnly  int64 // Reads that hit the shadow but missed the primary
+}
+
+// PrimaryHitRatio returns the fraction of reads that hit the primary
+func (s ShadowStats) PrimaryHitRatio() float64 {
+	if s.Reads == 0 {
+		return 0
+	}
+	return float64(s.PrimaryHits) / float64(s.Reads)
+}
+
+// ShadowHitRatio returns the fraction of reads that hit the shadow
+func (s ShadowStats) ShadowHitRatio() float64 {
+	if s.Reads == 0 {
+		return 0
+	}
+	return float64(s.ShadowHits) / float64(s.Reads)
+}
+
+// ShadowCache dark-launches a cache configuration, e.g. another policy or
+// size, next to the one in use. Reads and writes go to both caches but are
+// always served by the primary; the shadow only records whether it would
+// have hit. When the shadow misses a value the primary had, it is given the
+// primary's value, as a loader would have filled it. A ShadowCache is a Cacher.
+type ShadowCache struct {
+	primary, shadow *Cache
+
+	reads, primaryHits, shadowHits atomic.Int64
+	primaryOnly, shadowOnly        atomic.Int64
+}
+
+// NewShadowCache creates a cache served by primary and mirrored to shadow
+func NewShadowCache(primary, shadow *Cache) *ShadowCache {
+	return &ShadowCache{primary: primary, shadow: shadow}
+}
+
+// Get retrieves an item from the primary, reading the shadow for comparison
+func (s *ShadowCache) Get(key []byte) ([]byte, error) {
+	value, err := s.primary.Get(key)
+	if err != nil && !isMiss(err) {
+		return value, err
+	}
+	primaryHit := err == nil
+	_, shadowErr := s.shadow.Get(key)
+	shadowHit := shadowErr == nil
+
+	s.reads.Add(1)
+	switch {
+	case primaryHit && shadowHit:
+		s.primaryHits.Add(1)
+		s.shadowHits.Add(1)
+	case primaryHit:
+		s.primaryHits.Add(1)
+		s.primaryOnly.Add(1)
+		if err := s.shadow.Put(key, value); err != nil {
+			s.shadow.reportError(err)
+		}
+	case shadowHit:
+		s.shadowHits.Add(1)
+		s.shadowOnly.Add(1)
+	}
+	return value, err
+}
+
+// Has checks if a key exists in the primary
+func (s *ShadowCache) Has(key []byte) bool {
+	return s.primary.Has(key)
+}
+
+// Put stores an item in both caches with the given TTL, 0 for each cache's
+// TTL. Only the primary's errors are returned.
+func (s *ShadowCache) Put(key, value []byte, ttl time.Duration) error {
+	if err := s.shadow.PutWithTTL(key, value, ttl); err != nil {
+		s.shadow.reportError(err)
+	}
+	return s.primary.PutWithTTL(key, value, ttl)
+}
+
+// Delete removes an item from both caches, reporting whether the primary had it
+func (s *ShadowCache) Delete(key []byte) bool {
+	s.shadow.Delete(key)
+	return s.primary.Delete(key)
+}
+
+// Stats returns the hit ratio comparison gathered so far
+func (s *ShadowCache) Stats() ShadowStats {
+	return ShadowStats{
+		Reads:       s.reads.Load(),
+		PrimaryHits: s.primaryHits.Load(),
+		ShadowHits:  s.shadowHits.Load(),
+		PrimaryOnly: s.primaryOnly.Load(),
+		ShadowOnly:  s.shadowOnly.Load(),
+	}
+}
+
+// ResetStats starts a new comparison, e.g. after the shadow has warmed up
+func (s *ShadowCache) ResetStats() {
+	s.reads.Store(0)
+	s.primaryHits.Store(0)
+	s.shadowHits.Store(0)
+	s.primaryOnly.Store(0)
+	s.shadowOnly.Store(0)
+}
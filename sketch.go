@@ -0,0 +1,95 @@
+package cache
+
+import "math/bits"
+
+const (
+	sketchDepth      = 4  // Rows of the count-min sketch
+	sketchMaxCount   = 15 // Counters saturate like 4-bit counters
+	sketchResetRatio = 10 // Increments per counter column before counters are halved
+)
+
+// countMinSketch estimates how often keys were accessed recently. Counters
+// are halved periodically so the estimate follows changes in popularity.
+type countMinSketch struct {
+	rows      [sketchDepth][]uint8
+	mask      uint64
+	hash      func(key string) uint64
+	additions int
+	resetAt   int
+	resets    int // Times the counters were halved
+}
+
+// newCountMinSketch creates a sketch sized for the given number of keys
+func newCountMinSketch(keys int, hash func(key string) uint64) *countMinSketch {
+	width := 1 << bits.Len(uint(max(keys, 64)-1)) // Next power of two
+	s := &countMinSketch{
+		mask:    uint64(width - 1),
+		hash:    hash,
+		resetAt: width * sketchResetRatio,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// increment records an access of key
+func (s *countMinSketch) increment(key string) {
+	h := s.hash(key)
+	for i := range s.rows {
+		if idx := s.index(h, i); s.rows[i][idx] < sketchMaxCount {
+			s.rows[i][idx]++
+		}
+	}
+	s.additions++
+	if s.additions >= s.resetAt {
+		s.halve()
+	}
+}
+
+// estimate returns the estimated recent access count of key
+func (s *countMinSketch) estimate(key string) uint8 {
+	h := s.hash(key)
+	count := uint8(sketchMaxCount)
+	for i := range s.rows {
+		count = min(count, s.rows[i][s.index(h, i)])
+	}
+	return count
+}
+
+// index returns the column of a hash in row i
+func (s *countMinSketch) index(h uint64, i int) uint64 {
+	h2 := h>>32 | h<<32
+	return (h + uint64(i)*h2) & s.mask
+}
+
+// halve ages every counter
+func (s *countMinSketch) halve() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+	s.resets++
+}
+
+// Frequency returns an estimate, from 0 to 15, of how often a key was read or
+// written recently, even if it is not in the cache. It always returns 0
+// unless TrackFrequency is set.
+func (c *Cache) Frequency(key []byte) uint8 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.sketch == nil {
+		return 0
+	}
+	return c.sketch.estimate(c.storageKey(key))
+}
+
+// recordAccess counts an access of a key in the frequency sketch
+func (c *Cache) recordAccess(strKey string) {
+	if c.sketch != nil {
+		c.sketch.increment(strKey)
+	}
+}
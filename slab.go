@@ -0,0 +1,191 @@
+package cache
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"sync"
+	"time"
+
+	"github.com/dhyanio/discache/util"
+)
+
+const (
+	slabHeaderSize = 22     // Write time, key hash, key length and value length of an entry
+	slabPadding    = 0xFFFF // Key length marking the unused end of a ring buffer
+)
+
+// SlabOpts contains the configuration options for a slab cache
+type SlabOpts struct {
+	MaxBytes int           // Size of the ring buffers of all buckets together
+	Buckets  int           // Number of independently locked buckets, defaults to 256
+	TTL      time.Duration // 0 never expires entries
+}
+
+// SlabCache is a cache for users who care more about GC pauses than about
+// eviction accuracy. Keys hash to a bucket whose entries are packed into one
+// preallocated ring buffer, indexed by a map from key hashes to offsets, so
+// the garbage collector has no per-entry pointers to scan. New entries
+// overwrite the oldest ones, giving approximate FIFO eviction; reads do not
+// affect eviction. Overwritten and deleted values occupy the ring until
+// the write position wraps around to them.
+type SlabCache struct {
+	seed    maphash.Seed
+	ttl     time.Duration
+	buckets []slabBucket
+}
+
+// slabBucket is one ring buffer of a slab cache and its index
+type slabBucket struct {
+	mu    sync.RWMutex
+	index map[uint64]uint32 // Key hash to the offset of its entry
+	buf   []byte
+	head  int // Offset of the oldest entry
+	tail  int // Offset of the next write
+	used  int // Bytes from head to tail, including padding
+}
+
+// NewSlabCache creates a new slab cache
+func NewSlabCache(opts SlabOpts) *SlabCache {
+	n := opts.Buckets
+	if n <= 0 {
+		n = 256
+	}
+	c := &SlabCache{seed: maphash.MakeSeed(), ttl: opts.TTL, buckets: make([]slabBucket, n)}
+	for i := range c.buckets {
+		c.buckets[i].index = make(map[uint64]uint32)
+		c.buckets[i].buf = make([]byte, max(opts.MaxBytes/n, slabHeaderSize))
+	}
+	return c
+}
+
+// Get retrieves a copy of an item from the cache
+func (c *SlabCache) Get(key []byte) ([]byte, error) {
+	h := maphash.Bytes(c.seed, key)
+	b := c.bucket(h)
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	off, ok := b.index[h]
+	if !ok {
+		return nil, &util.KeyNotFoundError{Key: string(key)}
+	}
+	written, k, v := b.entry(int(off))
+	if string(k) != string(key) {
+		return nil, &util.KeyNotFoundError{Key: string(key)} // Hash collision
+	}
+	if c.ttl > 0 && time.Since(written) > c.ttl {
+		return nil, &util.ExpiredKeyError{Key: string(key)}
+	}
+	return append([]byte{}, v...), nil
+}
+
+// Put inserts an item into the cache, overwriting the oldest items of its
+// bucket if needed
+func (c *SlabCache) Put(key, value []byte) error {
+	n := slabHeaderSize + len(key) + len(value)
+	h := maphash.Bytes(c.seed, key)
+	b := c.bucket(h)
+	if n > len(b.buf) || len(key) >= slabPadding {
+		return ErrValueTooLarge
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Entries are contiguous, so one not fitting before the end of the
+	// buffer starts over at its beginning
+	padding := 0
+	if b.tail+n > len(b.buf) {
+		padding = len(b.buf) - b.tail
+	}
+	for b.used > 0 && b.used+padding+n > len(b.buf) {
+		b.pop()
+	}
+	if b.used == 0 {
+		b.head, b.tail, padding = 0, 0, 0
+	}
+	if padding > 0 {
+		if padding >= slabHeaderSize {
+			binary.BigEndian.PutUint16(b.buf[b.tail+16:], slabPadding)
+		}
+		b.used += padding
+		b.tail = 0
+	}
+
+	e := b.buf[b.tail : b.tail+n]
+	binary.BigEndian.PutUint64(e, uint64(time.Now().UnixNano()))
+	binary.BigEndian.PutUint64(e[8:], h)
+	binary.BigEndian.PutUint16(e[16:], uint16(len(key)))
+	binary.BigEndian.PutUint32(e[18:], uint32(len(value)))
+	copy(e[slabHeaderSize:], key)
+	copy(e[slabHeaderSize+len(key):], value)
+	b.index[h] = uint32(b.tail)
+	b.used += n
+	b.tail = (b.tail + n) % len(b.buf)
+	return nil
+}
+
+// Delete removes an item from the cache and reports whether it was present
+func (c *SlabCache) Delete(key []byte) bool {
+	h := maphash.Bytes(c.seed, key)
+	b := c.bucket(h)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	off, ok := b.index[h]
+	if !ok {
+		return false
+	}
+	if _, k, _ := b.entry(int(off)); string(k) != string(key) {
+		return false
+	}
+	delete(b.index, h)
+	return true
+}
+
+// Len returns the number of items in the cache, including expired ones
+func (c *SlabCache) Len() int {
+	n := 0
+	for i := range c.buckets {
+		b := &c.buckets[i]
+		b.mu.RLock()
+		n += len(b.index)
+		b.mu.RUnlock()
+	}
+	return n
+}
+
+// bucket returns the bucket of a key hash
+func (c *SlabCache) bucket(h uint64) *slabBucket {
+	return &c.buckets[h%uint64(len(c.buckets))]
+}
+
+// entry decodes the entry at an offset of the ring buffer
+func (b *slabBucket) entry(off int) (written time.Time, key, value []byte) {
+	e := b.buf[off:]
+	written = time.Unix(0, int64(binary.BigEndian.Uint64(e)))
+	keyLen := int(binary.BigEndian.Uint16(e[16:]))
+	valueLen := int(binary.BigEndian.Uint32(e[18:]))
+	key = e[slabHeaderSize : slabHeaderSize+keyLen]
+	value = e[slabHeaderSize+keyLen : slabHeaderSize+keyLen+valueLen]
+	return written, key, value
+}
+
+// pop evicts the oldest entry of the bucket, or skips the padding at the
+// end of the buffer
+func (b *slabBucket) pop() {
+	rest := len(b.buf) - b.head
+	if rest < slabHeaderSize || binary.BigEndian.Uint16(b.buf[b.head+16:]) == slabPadding {
+		b.used -= rest
+		b.head = 0
+		return
+	}
+	_, key, value := b.entry(b.head)
+	// The index may point to a newer entry of the same key instead
+	if h := binary.BigEndian.Uint64(b.buf[b.head+8:]); b.index[h] == uint32(b.head) {
+		delete(b.index, h)
+	}
+	n := slabHeaderSize + len(key) + len(value)
+	b.used -= n
+	b.head = (b.head + n) % len(b.buf)
+}
@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"time"
+)
+
+// Snapshot is an immutable point-in-time view of a cache. Taking one only
+// copies references to the stored values, which are never modified in place,
+// so it can be iterated or serialized for as long as needed without holding
+// the cache lock.
+type Snapshot struct {
+	taken   time.Time
+	order   []string // Keys from least to most recently used
+	entries map[string]snapshotEntry
+	keyFn   func(key []byte) string
+}
+
+// snapshotEntry is a single item captured by a snapshot
+type snapshotEntry struct {
+	parts [][]byte // The value, or its chunks
+	info  EntryInfo
+}
+
+// Snapshot returns a point-in-time view of the unexpired items in the cache
+func (c *Cache) Snapshot() (s *Snapshot) {
+	if c.CacheOpts.Audit != nil {
+		defer func(start time.Time) {
+			for _, key := range s.order {
+				c.audit(context.Background(), AuditGet, []byte(key), int(s.entries[key].info.Size), start, "hit", nil)
+			}
+		}(time.Now())
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return &Snapshot{entries: map[string]snapshotEntry{}, keyFn: c.storageKey}
+	}
+	return c.snapshot()
+}
+
+// snapshot takes a snapshot of the cache, which must be locked
+func (c *Cache) snapshot() *Snapshot {
+	s := &Snapshot{
+		taken:   time.Now(),
+		order:   make([]string, 0, c.items.Len()-len(c.chunkOwner)),
+		entries: make(map[string]snapshotEntry, c.items.Len()-len(c.chunkOwner)),
+		keyFn:   c.storageKey,
+	}
+	c.policy.Iterate(func(key string) bool {
+		if _, isChunk := c.chunkOwner[key]; isChunk || c.expired(key) {
+			return true
+		}
+		parts := [][]byte{c.stored(key)}
+		if c.transformerOf(key) != nil {
+			value, err := c.decoded(key)
+			if err != nil {
+				return true
+			}
+			parts[0] = value
+		} else if chunkKeys, ok := c.chunks[key]; ok {
+			parts = make([][]byte, len(chunkKeys))
+			for i, chunkKey := range chunkKeys {
+				parts[i] = c.stored(chunkKey)
+			}
+		}
+		s.order = append(s.order, key)
+		s.entries[key] = snapshotEntry{parts: parts, info: c.info(key)}
+		return true
+	})
+	return s
+}
+
+// Taken returns when the snapshot was taken
+func (s *Snapshot) Taken() time.Time {
+	return s.taken
+}
+
+// Len returns the number of items in the snapshot
+func (s *Snapshot) Len() int {
+	return len(s.order)
+}
+
+// Get retrieves an item from the snapshot
+func (s *Snapshot) Get(key []byte) ([]byte, bool) {
+	e, ok := s.entries[s.keyFn(key)]
+	if !ok {
+		return nil, false
+	}
+	return e.value(), true
+}
+
+// Range calls fn for each item from the least to the most recently used,
+// stopping early if fn returns false. key is the stored key.
+func (s *Snapshot) Range(fn func(key string, value []byte, meta EntryInfo) bool) {
+	for _, key := range s.order {
+		e := s.entries[key]
+		if !fn(key, e.value(), e.info) {
+			return
+		}
+	}
+}
+
+// value returns the entry's value, reassembling it if it is chunked
+func (e snapshotEntry) value() []byte {
+	if len(e.parts) == 1 {
+		return e.parts[0]
+	}
+	return bytes.Join(e.parts, nil)
+}
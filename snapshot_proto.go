@@ -0,0 +1,214 @@
+package cache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+// Protobuf wire types used by the snapshot format
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// errMalformedSnapshot is returned when a snapshot dump cannot be decoded
+var errMalformedSnapshot = errors.New("cache: malformed snapshot")
+
+// WriteTo writes the snapshot to w in the protobuf format defined in
+// snapshot.proto, one entry at a time. The encoding is written by hand to
+// keep the package free of dependencies, field by field in the order
+// protobuf encoders use, so dumps are byte for byte what they produce.
+func (s *Snapshot) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	write := func(b []byte) error {
+		m, err := bw.Write(b)
+		n += int64(m)
+		return err
+	}
+
+	// Like protobuf encoders, fields holding their zero value are left out
+	if !s.taken.IsZero() {
+		if err := write(appendVarintField(nil, 1, uint64(s.taken.UnixNano()))); err != nil {
+			return n, err
+		}
+	}
+	for _, key := range s.order {
+		e := s.entries[key]
+		var entry []byte
+		if key != "" {
+			entry = appendBytesField(entry, 1, []byte(key))
+		}
+		if value := e.value(); len(value) > 0 {
+			entry = appendBytesField(entry, 2, value)
+		}
+		if !e.info.Written.IsZero() {
+			entry = appendVarintField(entry, 3, uint64(e.info.Written.UnixNano()))
+		}
+		if !e.info.Expires.IsZero() {
+			entry = appendVarintField(entry, 4, uint64(e.info.Expires.UnixNano()))
+		}
+		if e.info.Namespace != "" {
+			entry = appendBytesField(entry, 5, []byte(e.info.Namespace))
+		}
+		if err := write(appendBytesField(nil, 2, entry)); err != nil {
+			return n, err
+		}
+	}
+	return n, bw.Flush()
+}
+
+// ReadSnapshot reads a snapshot written by WriteTo. Keys of the returned
+// snapshot are looked up exactly as stored.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{
+		entries: make(map[string]snapshotEntry),
+		keyFn:   func(key []byte) string { return string(key) },
+	}
+	err = decodeFields(data, func(field int, v uint64, b []byte) error {
+		switch field {
+		case 1:
+			s.taken = time.Unix(0, int64(v))
+		case 2:
+			key, e, err := decodeEntry(b)
+			if err != nil {
+				return err
+			}
+			if _, dup := s.entries[key]; !dup {
+				s.order = append(s.order, key)
+			}
+			s.entries[key] = e
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Restore loads the unexpired entries of a snapshot into the cache, keeping
+// their write times and expiry deadlines. Restored entries become the most
+// recently used in the snapshot's recency order.
+func (c *Cache) Restore(s *Snapshot) error {
+	defer c.reclaimGroup() // Runs after unlocking
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	if c.frozen {
+		return c.frozenError()
+	}
+	now := time.Now()
+	for _, key := range s.order {
+		e := s.entries[key]
+		if !e.info.Expires.IsZero() && !now.Before(e.info.Expires) {
+			continue
+		}
+		if err := c.put(key, e.value()); err != nil {
+			return err
+		}
+		c.setWritten(key, e.info.Written)
+		if !e.info.Expires.IsZero() {
+			c.setTTL(key, e.info.Expires.Sub(e.info.Written))
+		}
+	}
+	return nil
+}
+
+// decodeEntry decodes an Entry message
+func decodeEntry(data []byte) (string, snapshotEntry, error) {
+	var key string
+	var e snapshotEntry
+	err := decodeFields(data, func(field int, v uint64, b []byte) error {
+		switch field {
+		case 1:
+			key = string(b)
+		case 2:
+			e.parts = [][]byte{append([]byte{}, b...)}
+		case 3:
+			e.info.Written = time.Unix(0, int64(v))
+		case 4:
+			e.info.Expires = time.Unix(0, int64(v))
+		case 5:
+			e.info.Namespace = string(b)
+		}
+		return nil
+	})
+	if e.parts == nil {
+		e.parts = [][]byte{{}}
+	}
+	e.info.Size = int64(len(e.parts[0]))
+	return key, e, err
+}
+
+// decodeFields calls fn with the number and value of each field of a
+// message, skipping wire types the snapshot format does not use
+func decodeFields(data []byte, fn func(field int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errMalformedSnapshot
+		}
+		data = data[n:]
+		field, wireType := int(tag>>3), int(tag&7)
+
+		var v uint64
+		var b []byte
+		switch wireType {
+		case wireVarint:
+			v, n = binary.Uvarint(data)
+			if n <= 0 {
+				return errMalformedSnapshot
+			}
+			data = data[n:]
+		case wireBytes:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < size {
+				return errMalformedSnapshot
+			}
+			b = data[n : n+int(size)]
+			data = data[n+int(size):]
+		case wireFixed64, wireFixed32:
+			size := 8
+			if wireType == wireFixed32 {
+				size = 4
+			}
+			if len(data) < size {
+				return errMalformedSnapshot
+			}
+			data = data[size:]
+			continue
+		default:
+			return errMalformedSnapshot
+		}
+		if err := fn(field, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendVarintField appends a varint field to a message
+func appendVarintField(b []byte, field int, v uint64) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|wireVarint)
+	return binary.AppendUvarint(b, v)
+}
+
+// appendBytesField appends a length-delimited field to a message
+func appendBytesField(b []byte, field int, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+// protoSnapshot is a Snapshot message as encoded by the protobuf Go encoder
+// (google.golang.org/protobuf, deterministic Marshal) from snapshot.proto,
+// holding the entries of TestReadSnapshotDecodesProtobuf
+const protoSnapshot = "08959a97ece39fe7cb17" +
+	"121f0a06757365723a311205616c696365188080a8b1e39fe7cb172a0475736572" +
+	"121b0a05656d707479188180a8b1e39fe7cb1720808098b5f5f9b3f738" +
+	"121a0a016b120176188280a8b1e39fe7cb1720818098b5f5f9b3f738"
+
+func TestReadSnapshotDecodesProtobuf(t *testing.T) {
+	data, err := hex.DecodeString(protoSnapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := ReadSnapshot(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadSnapshot: %v", err)
+	}
+
+	tests := []struct {
+		key       string
+		value     string
+		written   int64
+		expires   int64
+		namespace string
+	}{
+		{"user:1", "alice", 1700000000000000000, 0, "user"},
+		{"empty", "", 1700000000000000001, 4102444800000000000, ""},
+		{"k", "v", 1700000000000000002, 4102444800000000001, ""},
+	}
+	if got := s.Taken().UnixNano(); got != 1700000000123456789 {
+		t.Errorf("Taken = %d, want 1700000000123456789", got)
+	}
+	if s.Len() != len(tests) {
+		t.Fatalf("Len = %d, want %d", s.Len(), len(tests))
+	}
+	i := 0
+	s.Range(func(key string, value []byte, meta EntryInfo) bool {
+		tt := tests[i]
+		i++
+		if key != tt.key || string(value) != tt.value || meta.Namespace != tt.namespace {
+			t.Errorf("entry %d = %q %q %q, want %q %q %q", i, key, value, meta.Namespace, tt.key, tt.value, tt.namespace)
+		}
+		if meta.Written.UnixNano() != tt.written {
+			t.Errorf("%s: Written = %d, want %d", key, meta.Written.UnixNano(), tt.written)
+		}
+		if (tt.expires == 0) != meta.Expires.IsZero() || (tt.expires != 0 && meta.Expires.UnixNano() != tt.expires) {
+			t.Errorf("%s: Expires = %v, want %d", key, meta.Expires, tt.expires)
+		}
+		return true
+	})
+
+	var buf bytes.Buffer
+	if _, err := s.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	if got := hex.EncodeToString(buf.Bytes()); got != protoSnapshot {
+		t.Errorf("WriteTo = %s, want the protobuf encoding %s", got, protoSnapshot)
+	}
+}
+
+func TestSnapshotDumpRestores(t *testing.T) {
+	tests := []struct {
+		name string
+		opts CacheOpts
+	}{
+		{"plain", CacheOpts{Capacity: 10}},
+		{"chunked", CacheOpts{Capacity: 100, ChunkSize: 4}},
+		{"namespaced", CacheOpts{Capacity: 10, Namespaces: map[string]NamespaceOpts{"user": {}}}},
+	}
+	values := map[string]string{"user:1": "alice", "long": "a value spanning chunks", "empty": ""}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(tt.opts)
+			defer c.Close(context.Background())
+			for key, value := range values {
+				if err := c.PutWithTTL([]byte(key), []byte(value), time.Hour); err != nil {
+					t.Fatalf("Put %s: %v", key, err)
+				}
+			}
+			c.Put([]byte("gone"), []byte("x"))
+			c.Delete([]byte("gone"))
+
+			var buf bytes.Buffer
+			if _, err := c.Snapshot().WriteTo(&buf); err != nil {
+				t.Fatalf("WriteTo: %v", err)
+			}
+			s, err := ReadSnapshot(&buf)
+			if err != nil {
+				t.Fatalf("ReadSnapshot: %v", err)
+			}
+			restored := NewCache(tt.opts)
+			defer restored.Close(context.Background())
+			if err := restored.Restore(s); err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			for key, want := range values {
+				if got, err := restored.Get([]byte(key)); err != nil || string(got) != want {
+					t.Errorf("Get %s = %q, %v; want %q", key, got, err, want)
+				}
+			}
+			if restored.Has([]byte("gone")) {
+				t.Error("a deleted item was restored")
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSnapshotIsPointInTime(t *testing.T) {
+	aes, err := AESTransformer(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		opts CacheOpts
+	}{
+		{"plain", CacheOpts{Capacity: 100}},
+		{"chunked", CacheOpts{Capacity: 100, ChunkSize: 4}},
+		{"transformed", CacheOpts{Capacity: 100, Transform: aes}},
+		{"hashed keys", CacheOpts{Capacity: 100, HashKeys: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(tt.opts)
+			defer c.Close(context.Background())
+			c.Put([]byte("a"), []byte("first"))
+			c.Put([]byte("b"), []byte("a value spanning chunks"))
+			c.PutWithTTL([]byte("gone"), []byte("x"), time.Millisecond)
+			time.Sleep(5 * time.Millisecond)
+			c.Get([]byte("a")) // a becomes the most recently used
+
+			s := c.Snapshot()
+			c.Put([]byte("a"), []byte("second"))
+			c.Put([]byte("c"), []byte("later"))
+			c.Delete([]byte("b"))
+
+			if s.Len() != 2 {
+				t.Errorf("Len = %d, want 2", s.Len())
+			}
+			want := map[string]string{"a": "first", "b": "a value spanning chunks"}
+			for key, value := range want {
+				if got, ok := s.Get([]byte(key)); !ok || string(got) != value {
+					t.Errorf("Get %s = %q, %v; want %q", key, got, ok, value)
+				}
+			}
+			for _, key := range []string{"c", "gone"} {
+				if _, ok := s.Get([]byte(key)); ok {
+					t.Errorf("Get %s found an item missing when the snapshot was taken", key)
+				}
+			}
+
+			var values []string
+			s.Range(func(_ string, value []byte, meta EntryInfo) bool {
+				values = append(values, string(value))
+				if tt.opts.Transform == nil && meta.Size != int64(len(value)) {
+					t.Errorf("Size of %q = %d", value, meta.Size)
+				}
+				return true
+			})
+			if len(values) != 2 || values[0] != want["b"] || values[1] != "first" {
+				t.Errorf("Range = %q, want least to most recently used", values)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	gob.Register(time.Time{})
+}
+
+// Rows is a query result materialized from the database or the cache
+type Rows struct {
+	Columns []string
+	Values  [][]any
+}
+
+// Len returns the number of rows in the result
+func (r *Rows) Len() int {
+	return len(r.Values)
+}
+
+// QueryCache wraps a *sql.DB and caches query results keyed by the normalized
+// query text and arguments. Each cached result is tagged with the tables it
+// reads so writes to a table invalidate every dependent result.
+type QueryCache struct {
+	db    *sql.DB
+	cache *Cache
+
+	mu     sync.Mutex
+	tags   map[string]map[string]struct{} // Table to the keys of results that read it
+	gens   map[string]uint64              // Table invalidation generations
+	pruned map[string]int                 // Size of each table's tags after they were last pruned
+}
+
+// NewQueryCache creates a new query cache in front of db
+func NewQueryCache(db *sql.DB, c *Cache) *QueryCache {
+	return &QueryCache{
+		db:     db,
+		cache:  c,
+		tags:   make(map[string]map[string]struct{}),
+		gens:   make(map[string]uint64),
+		pruned: make(map[string]int),
+	}
+}
+
+// Query returns the result of the query, reading it from the cache when
+// possible. tables lists the tables the query reads from.
+func (q *QueryCache) Query(ctx context.Context, tables []string, query string, args ...any) (*Rows, error) {
+	key := queryKey(query, args)
+	if data, err := q.cache.Get([]byte(key)); err == nil {
+		rows := &Rows{}
+		err := gob.NewDecoder(bytes.NewReader(data)).Decode(rows)
+		if err == nil {
+			return rows, nil
+		}
+		q.cache.reportError(fmt.Errorf("sqlcache: decoding result: %w", err))
+	}
+
+	gens := q.generations(tables)
+	rows, err := q.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(rows); err != nil {
+		q.cache.reportError(fmt.Errorf("sqlcache: encoding result: %w", err))
+		return rows, nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	// Skip the fill if any table was invalidated while the query ran
+	for _, table := range tables {
+		if q.gens[table] != gens[table] {
+			return rows, nil
+		}
+	}
+	if err := q.cache.Put([]byte(key), buf.Bytes()); err != nil {
+		q.cache.reportError(fmt.Errorf("sqlcache: storing result: %w", err))
+		return rows, nil
+	}
+	for _, table := range tables {
+		if q.tags[table] == nil {
+			q.tags[table] = make(map[string]struct{})
+		}
+		q.tags[table][key] = struct{}{}
+		q.prune(table)
+	}
+	return rows, nil
+}
+
+// prune forgets the tagged results of a table that were evicted or expired,
+// once its tags doubled since they were last pruned, so they do not grow
+// without bound between invalidations
+func (q *QueryCache) prune(table string) {
+	keys := q.tags[table]
+	if len(keys) <= 2*q.pruned[table] {
+		return
+	}
+	q.cache.mu.RLock()
+	for key := range keys {
+		if !q.cache.live(q.cache.storageKey([]byte(key)), []byte(key)) {
+			delete(keys, key)
+		}
+	}
+	q.cache.mu.RUnlock()
+	q.pruned[table] = len(keys)
+}
+
+// Exec executes a statement against the database and invalidates the cached
+// results of every table it writes to
+func (q *QueryCache) Exec(ctx context.Context, tables []string, query string, args ...any) (sql.Result, error) {
+	res, err := q.db.ExecContext(ctx, query, args...)
+	q.Invalidate(tables...)
+	return res, err
+}
+
+// Invalidate removes all cached results that read from the given tables
+func (q *QueryCache) Invalidate(tables ...string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, table := range tables {
+		q.gens[table]++
+		for key := range q.tags[table] {
+			q.cache.Delete([]byte(key))
+		}
+		delete(q.tags, table)
+		delete(q.pruned, table)
+	}
+}
+
+// generations returns the current invalidation generation of each table
+func (q *QueryCache) generations(tables []string) map[string]uint64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	gens := make(map[string]uint64, len(tables))
+	for _, table := range tables {
+		gens[table] = q.gens[table]
+	}
+	return gens
+}
+
+// query runs the query and reads all of its rows into memory
+func (q *QueryCache) query(ctx context.Context, query string, args ...any) (*Rows, error) {
+	sqlRows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlRows.Close()
+
+	columns, err := sqlRows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	rows := &Rows{Columns: columns}
+	for sqlRows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := sqlRows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			// Drivers may reuse the memory of byte slices between rows
+			if b, ok := v.([]byte); ok {
+				values[i] = append([]byte(nil), b...)
+			}
+		}
+		rows.Values = append(rows.Values, values)
+	}
+	if err := sqlRows.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// queryKey builds the cache key from the normalized query and its arguments
+func queryKey(query string, args []any) string {
+	var b strings.Builder
+	b.WriteString("sql:")
+	b.WriteString(strings.Join(strings.Fields(query), " "))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "\x00%T:%v", arg, arg)
+	}
+	return b.String()
+}
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+)
+
+// fakeDB is a database/sql driver counting the queries that reach it. Every
+// query returns one row holding the count so far.
+type fakeDB struct {
+	queries int64
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{db}, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return fakeStmt(c), nil }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+type fakeStmt struct{ db *fakeDB }
+
+func (s fakeStmt) Close() error                               { return nil }
+func (s fakeStmt) NumInput() int                              { return -1 }
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.db.queries++
+	return &fakeRows{n: s.db.queries}, nil
+}
+
+type fakeRows struct {
+	n    int64
+	read bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"n"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.read {
+		return io.EOF
+	}
+	r.read = true
+	dest[0] = r.n
+	return nil
+}
+
+func TestQueryCache(t *testing.T) {
+	const users = "SELECT * FROM users WHERE id = ?"
+	type step struct {
+		exec, invalidate bool
+		tables           []string
+		query            string
+		arg              any
+	}
+	query := func(tables []string, query string, arg any) step {
+		return step{tables: tables, query: query, arg: arg}
+	}
+	tests := []struct {
+		name    string
+		steps   []step
+		queries int64 // Queries reaching the database
+	}{
+		{name: "repeated", steps: []step{query([]string{"users"}, users, 1), query([]string{"users"}, users, 1)}, queries: 1},
+		{name: "normalized", steps: []step{query([]string{"users"}, users, 1), query([]string{"users"}, "SELECT *\n\tFROM users  WHERE id = ?", 1)}, queries: 1},
+		{name: "other args", steps: []step{query([]string{"users"}, users, 1), query([]string{"users"}, users, 2)}, queries: 2},
+		{name: "arg types", steps: []step{query([]string{"users"}, users, 1), query([]string{"users"}, users, "1")}, queries: 2},
+		{
+			name:    "exec",
+			steps:   []step{query([]string{"users"}, users, 1), {exec: true, tables: []string{"users"}, query: "DELETE FROM users"}, query([]string{"users"}, users, 1)},
+			queries: 2,
+		},
+		{
+			name:    "exec other table",
+			steps:   []step{query([]string{"users"}, users, 1), {exec: true, tables: []string{"orders"}, query: "DELETE FROM orders"}, query([]string{"users"}, users, 1)},
+			queries: 1,
+		},
+		{
+			name:    "invalidate joined table",
+			steps:   []step{query([]string{"users", "orders"}, users, 1), {invalidate: true, tables: []string{"orders"}}, query([]string{"users", "orders"}, users, 1)},
+			queries: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(CacheOpts{Capacity: 10})
+			defer c.Close(context.Background())
+			fake := &fakeDB{}
+			db := sql.OpenDB(fake)
+			defer db.Close()
+			q := NewQueryCache(db, c)
+
+			var rows *Rows
+			for _, s := range tt.steps {
+				var err error
+				switch {
+				case s.invalidate:
+					q.Invalidate(s.tables...)
+				case s.exec:
+					_, err = q.Exec(context.Background(), s.tables, s.query)
+				default:
+					rows, err = q.Query(context.Background(), s.tables, s.query, s.arg)
+				}
+				if err != nil {
+					t.Fatalf("step %+v = %v", s, err)
+				}
+			}
+
+			if fake.queries != tt.queries {
+				t.Errorf("database queries = %d, want %d", fake.queries, tt.queries)
+			}
+			if rows.Len() != 1 || rows.Values[0][0] != fake.queries {
+				t.Errorf("last result = %v, want [[%d]]", rows.Values, fake.queries)
+			}
+		})
+	}
+}
+
+func TestQueryCachePrunesTags(t *testing.T) {
+	const capacity = 4
+	c := NewCache(CacheOpts{Capacity: capacity})
+	defer c.Close(context.Background())
+	db := sql.OpenDB(&fakeDB{})
+	defer db.Close()
+	q := NewQueryCache(db, c)
+
+	for i := range 100 {
+		if _, err := q.Query(context.Background(), []string{"users"}, "SELECT * FROM users WHERE id = ?", i); err != nil {
+			t.Fatalf("Query = %v", err)
+		}
+		if n := len(q.tags["users"]); n > 2*capacity+1 {
+			t.Fatalf("%d tagged results after %d queries, want at most %d", n, i+1, 2*capacity+1)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/dhyanio/discache/util"
+)
+
+// GetStale retrieves an item even if it expired but was not removed yet,
+// for callers serving stale values while their backend is failing. For an
+// expired item it returns how long ago the item expired, 0 if it was
+// invalidated by BumpGeneration, and leaves the item and its recency as they
+// are. Stale items are checked like Get checks fresh ones, so items failing
+// their checksum or the Validate hook are removed rather than returned.
+// Fresh items are returned like Get does. Items are removed promptly after
+// expiring when ExpiryTick is set, leaving little to return.
+func (c *Cache) GetStale(key []byte) (value []byte, expiredAgo time.Duration, err error) {
+	value, expiredAgo, _, err = c.getStale(context.Background(), key)
+	return value, expiredAgo, err
+}
+
+// getStale is GetStale also reporting whether the value is fresh
+func (c *Cache) getStale(ctx context.Context, key []byte) (value []byte, expiredAgo time.Duration, fresh bool, err error) {
+	if c.CacheOpts.Audit != nil {
+		defer func(start time.Time) {
+			outcome := "hit"
+			if !fresh {
+				outcome = "stale"
+			}
+			c.audit(ctx, AuditGet, key, len(value), start, outcome, err)
+		}(time.Now())
+	}
+	if err := c.limit(ctx, key); err != nil {
+		return nil, 0, false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, 0, false, ErrClosed
+	}
+
+	strKey := c.storageKey(key)
+	if _, found := c.items.Get(strKey); !found || c.collides(strKey, key) || !c.expired(strKey) {
+		strKey, err := c.lookup(key)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		value, err := c.read(strKey)
+		return value, 0, err == nil, err
+	}
+	if !c.intact(strKey) {
+		c.trace(strKey, "evict", "checksum")
+		c.remove(strKey)
+		c.misses++
+		return nil, 0, false, ErrCorrupted
+	}
+	if !c.valid(strKey) {
+		c.trace(strKey, "evict", "validate")
+		c.remove(strKey)
+		c.invalidations++
+		c.misses++
+		return nil, 0, false, &util.KeyNotFoundError{Key: string(key)}
+	}
+	c.misses++
+	if expires := c.info(strKey).Expires; !expires.IsZero() {
+		expiredAgo = c.now().Sub(expires)
+	}
+	value, err = c.read(strKey)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return value, expiredAgo, false, nil
+}
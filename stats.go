@@ -0,0 +1,80 @@
+package cache
+
+// Metrics contains the counters of a cache
+type Metrics struct {
+	Hits        int
+	Misses      int
+	Evictions   int // Items removed to make room for others
+	Expirations int // Items removed because their TTL elapsed or a dependency went
+	Shed        int // Puts dropped by the pressure policy
+	Rejected    int // Puts of first-seen keys rejected by the doorkeeper
+
+	ExpireDropped int // OnExpire notifications dropped past ExpireQueueLimit
+
+	Invalidations int // Items removed because Validate rejected them
+	Drift         int // Bookkeeping inconsistencies repaired by Verify
+}
+
+// Metrics returns a consistent snapshot of the cache counters
+func (c *Cache) Metrics() Metrics {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.metrics()
+}
+
+// metrics returns the cache counters, with the cache locked
+func (c *Cache) metrics() Metrics {
+	c.expiredMu.Lock()
+	dropped := c.expiredDropped
+	c.expiredMu.Unlock()
+	return Metrics{
+		Hits:        c.hits,
+		Misses:      c.misses,
+		Evictions:   c.evictions,
+		Expirations: c.expirations,
+		Shed:        c.shed,
+		Rejected:    c.rejected,
+
+		ExpireDropped: dropped,
+		Invalidations: c.invalidations,
+		Drift:         c.drift,
+	}
+}
+
+// Len returns the number of items in the cache without taking the lock.
+// Expired items count until they are removed.
+func (c *Cache) Len() int {
+	return int(c.entries.Load())
+}
+
+// SizeBytes returns the bytes stored in the cache, including chunk manifests,
+// without taking the lock
+func (c *Cache) SizeBytes() int64 {
+	return c.bytes.Load()
+}
+
+// PurgeExpired removes every expired item from the cache and returns how many were removed
+func (c *Cache) PurgeExpired() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed || (c.CacheOpts.TTL <= 0 && len(c.ttls) == 0 && len(c.nsGens) == 0 && len(c.CacheOpts.Namespaces) == 0) {
+		return 0
+	}
+	var expired []string
+	c.items.Iterate(func(key string, _ []byte) bool {
+		if _, isChunk := c.chunkOwner[key]; !isChunk && c.expired(key) {
+			expired = append(expired, key)
+		}
+		return true
+	})
+	// Count removals rather than matches: expiring an item may have removed
+	// matched dependents already, and removes unmatched ones along with it
+	before := c.expirations
+	for _, key := range expired {
+		if _, found := c.items.Get(key); found {
+			c.expire(key)
+		}
+	}
+	return c.expirations - before
+}
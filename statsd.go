@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// statsdLoop pushes the cache counters and gauges to StatsDAddr every
+// StatsDInterval
+func (c *Cache) statsdLoop(done <-chan struct{}) {
+	interval := c.CacheOpts.StatsDInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	conn, err := net.Dial("udp", c.CacheOpts.StatsDAddr)
+	if err != nil {
+		c.reportError(fmt.Errorf("dialing statsd: %w", err))
+		return
+	}
+	defer conn.Close()
+
+	var last Metrics // Counters start from zero
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			m := c.Metrics()
+			if _, err := conn.Write(c.statsdPacket(last, m)); err != nil {
+				c.reportError(fmt.Errorf("writing statsd: %w", err))
+			}
+			last = m
+		}
+	}
+}
+
+// statsdPacket formats the counter increments between two snapshots and the
+// current gauges as StatsD lines, with DogStatsD tags if any
+func (c *Cache) statsdPacket(from, to Metrics) []byte {
+	var tags string
+	if len(c.CacheOpts.StatsDTags) > 0 {
+		tags = "|#" + strings.Join(c.CacheOpts.StatsDTags, ",")
+	}
+	var b strings.Builder
+	line := func(name string, value int64, kind string) {
+		fmt.Fprintf(&b, "%s%s:%d|%s%s\n", c.CacheOpts.StatsDPrefix, name, value, kind, tags)
+	}
+	line("hits", int64(to.Hits-from.Hits), "c")
+	line("misses", int64(to.Misses-from.Misses), "c")
+	line("evictions", int64(to.Evictions-from.Evictions), "c")
+	line("expirations", int64(to.Expirations-from.Expirations), "c")
+	line("shed", int64(to.Shed-from.Shed), "c")
+	line("rejected", int64(to.Rejected-from.Rejected), "c")
+	line("invalidations", int64(to.Invalidations-from.Invalidations), "c")
+	line("expire_dropped", int64(to.ExpireDropped-from.ExpireDropped), "c")
+	line("items", int64(c.Len()), "g")
+	line("bytes", c.SizeBytes(), "g")
+	return []byte(strings.TrimSuffix(b.String(), "\n"))
+}
@@ -0,0 +1,55 @@
+package cache
+
+// Store holds the entries of a cache. Eviction, TTLs and stats stay in the
+// cache, which calls the store under its own lock, so implementations need
+// not be safe for concurrent use. Values are never modified in place after
+// Set, and a slice returned by Get must stay valid until its key is set
+// again or deleted.
+type Store interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
+	Len() int
+	// Iterate calls fn for every entry in no particular order until fn
+	// returns false. fn does not modify the store.
+	Iterate(fn func(key string, value []byte) bool)
+}
+
+// mapStore is the default Store, backed by a Go map
+type mapStore map[string][]byte
+
+// NewMapStore creates the default map-backed Store
+func NewMapStore() Store {
+	return make(mapStore)
+}
+
+func (s mapStore) Get(key string) ([]byte, bool) {
+	value, ok := s[key]
+	return value, ok
+}
+
+func (s mapStore) Set(key string, value []byte) {
+	s[key] = value
+}
+
+func (s mapStore) Delete(key string) {
+	delete(s, key)
+}
+
+func (s mapStore) Len() int {
+	return len(s)
+}
+
+func (s mapStore) Iterate(fn func(key string, value []byte) bool) {
+	for key, value := range s {
+		if !fn(key, value) {
+			return
+		}
+	}
+}
+
+// stored returns the value held in the store for a single entry
+func (c *Cache) stored(key string) []byte {
+	value, _ := c.items.Get(key)
+	return value
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+)
+
+// GetReader retrieves an item from the cache as a stream and updates its usage.
+// Chunked values are streamed chunk by chunk without being reassembled.
+func (c *Cache) GetReader(key []byte) (_ io.ReadCloser, err error) {
+	var size int
+	if c.CacheOpts.Audit != nil {
+		defer func(start time.Time) { c.audit(context.Background(), AuditGet, key, size, start, "hit", err) }(time.Now())
+	}
+	if err := c.limit(context.Background(), key); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, ErrClosed
+	}
+
+	strKey, err := c.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.transformerOf(strKey) != nil {
+		value, err := c.read(strKey)
+		if err != nil {
+			return nil, err
+		}
+		size = len(value)
+		return io.NopCloser(bytes.NewReader(value)), nil
+	}
+
+	// Stored values are never modified in place, so they can be read after unlocking
+	readers := []io.Reader{bytes.NewReader(c.stored(strKey))}
+	size = len(c.stored(strKey))
+	if chunkKeys, ok := c.chunks[strKey]; ok {
+		readers, size = readers[:0], 0
+		for _, chunkKey := range chunkKeys {
+			readers = append(readers, bytes.NewReader(c.stored(chunkKey)))
+			size += len(c.stored(chunkKey))
+		}
+	}
+	return io.NopCloser(io.MultiReader(readers...)), nil
+}
+
+// PutReader inserts an item read from r into the cache. size is the number of
+// bytes to read, or -1 to read until EOF. When chunking is enabled the value
+// is read directly into chunks without materializing it as a whole.
+func (c *Cache) PutReader(key []byte, r io.Reader, size int64) error {
+	if size >= 0 {
+		r = io.LimitReader(r, size)
+	}
+
+	// Transformed values are encoded as a whole before they are chunked
+	chunkSize := c.CacheOpts.ChunkSize
+	if chunkSize <= 0 || (size >= 0 && size <= int64(chunkSize)) || c.transformerOf(string(key)) != nil {
+		value, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if size >= 0 && int64(len(value)) != size {
+			return io.ErrUnexpectedEOF
+		}
+		return c.Put(key, value)
+	}
+
+	var parts [][]byte
+	var total int64
+	for {
+		buf := make([]byte, chunkSize)
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			parts = append(parts, buf[:n])
+			total += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if size >= 0 && total != size {
+		return io.ErrUnexpectedEOF
+	}
+	if len(parts) <= 1 {
+		return c.Put(key, bytes.Join(parts, nil))
+	}
+	return c.writeWith(context.Background(), key, int(total), writeOpts{}, func(strKey string) (int, error) {
+		return int(total), c.putChunks(strKey, parts)
+	})
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPutReaderGetReader(t *testing.T) {
+	value := "a value long enough to span several chunks"
+	tests := []struct {
+		name      string
+		chunkSize int
+		r         io.Reader
+		size      int64
+		wantErr   error
+		want      string
+	}{
+		{name: "until EOF", r: strings.NewReader(value), size: -1, want: value},
+		{name: "exact size", r: strings.NewReader(value), size: int64(len(value)), want: value},
+		{name: "prefix", r: strings.NewReader(value), size: 7, want: value[:7]},
+		{name: "short", r: strings.NewReader(value), size: 100, wantErr: io.ErrUnexpectedEOF},
+		{name: "chunked until EOF", chunkSize: 8, r: strings.NewReader(value), size: -1, want: value},
+		{name: "chunked exact size", chunkSize: 8, r: strings.NewReader(value), size: int64(len(value)), want: value},
+		{name: "chunked short", chunkSize: 8, r: strings.NewReader(value), size: 100, wantErr: io.ErrUnexpectedEOF},
+		{name: "chunked empty", chunkSize: 8, r: strings.NewReader(""), size: -1, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(CacheOpts{Capacity: 100, ChunkSize: tt.chunkSize})
+			defer c.Close(context.Background())
+
+			err := c.PutReader([]byte("k"), tt.r, tt.size)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PutReader = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if c.Has([]byte("k")) {
+					t.Error("a failed PutReader stored a value")
+				}
+				return
+			}
+			r, err := c.GetReader([]byte("k"))
+			if err != nil {
+				t.Fatalf("GetReader: %v", err)
+			}
+			defer r.Close()
+			got, err := io.ReadAll(r)
+			if err != nil || string(got) != tt.want {
+				t.Errorf("read %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetReaderOutlivesOverwrite(t *testing.T) {
+	c := NewCache(CacheOpts{Capacity: 100, ChunkSize: 4})
+	defer c.Close(context.Background())
+	c.Put([]byte("k"), []byte("0123456789"))
+	r, err := c.GetReader([]byte("k"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Put([]byte("k"), []byte("abcdefghij"))
+	if got, _ := io.ReadAll(r); !bytes.Equal(got, []byte("0123456789")) {
+		t.Errorf("reader opened before an overwrite read %q", got)
+	}
+	if _, err := c.GetReader([]byte("missing")); !isMiss(err) {
+		t.Errorf("GetReader of a missing key = %v, want a miss", err)
+	}
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Fill stores a value read from the backing store at readAt, unless the key
+// was deleted after that under TombstoneTTL, in which case it fails with
+// ErrStaleFill rather than resurrect the deleted value. A ttl of 0 uses the
+// cache TTL.
+func (c *Cache) Fill(key, value []byte, ttl time.Duration, readAt time.Time) error {
+	return c.write(context.Background(), key, value, writeOpts{ttl: ttl, readAt: readAt})
+}
+
+// bury leaves a tombstone for a deleted key, sweeping expired tombstones
+// once per TombstoneTTL
+func (c *Cache) bury(strKey string) {
+	ttl := c.CacheOpts.TombstoneTTL
+	if ttl <= 0 {
+		return
+	}
+	now := c.now()
+	if now.Sub(c.lastSweep) >= ttl {
+		for key, deleted := range c.tombstones {
+			if now.Sub(deleted) >= ttl {
+				delete(c.tombstones, key)
+			}
+		}
+		c.lastSweep = now
+	}
+	c.tombstones[strKey] = now
+}
+
+// checkTombstone fails a fill of a value read at readAt if the key was
+// deleted since. A zero readAt is an authoritative write, which lifts the
+// key's tombstone.
+func (c *Cache) checkTombstone(strKey string, readAt time.Time) error {
+	deleted, ok := c.tombstones[strKey]
+	if !ok {
+		return nil
+	}
+	if readAt.IsZero() || c.now().Sub(deleted) >= c.CacheOpts.TombstoneTTL {
+		delete(c.tombstones, strKey)
+		return nil
+	}
+	if !readAt.After(deleted) { // Reads at the same clock reading may predate the delete
+		return ErrStaleFill
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"fmt"
+	"hash/maphash"
+	"math"
+	"time"
+)
+
+// trace writes an admission or eviction decision about a key to the Trace
+// writer, if the key is sampled. Decisions are "admit", "reject", "promote"
+// and "evict", with the reason of rejections and evictions.
+func (c *Cache) trace(key, decision, reason string) {
+	if c.CacheOpts.Trace == nil {
+		return
+	}
+	if owner, ok := c.chunkOwner[key]; ok {
+		key = owner
+	}
+	if rate := c.CacheOpts.TraceSampleRate; rate > 0 && rate < 1 {
+		// Sample by key so that every decision about a traced key is kept
+		if float64(maphash.String(c.keySeed, key)) >= rate*math.MaxUint64 {
+			return
+		}
+	}
+	line := fmt.Sprintf("%s %s key=%q", time.Now().UTC().Format(time.RFC3339Nano), decision, c.displayKey([]byte(key)))
+	if reason != "" {
+		line += " reason=" + reason
+	}
+	if _, err := fmt.Fprintln(c.CacheOpts.Trace, line); err != nil {
+		c.reportError(fmt.Errorf("writing trace: %w", err))
+	}
+}
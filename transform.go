@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// Transformer encodes values on their way into a cache and decodes them on
+// the way out, e.g. to compress or encrypt them in memory. Encode must not
+// modify its argument, and Decode must reverse Encode.
+type Transformer interface {
+	Encode(value []byte) ([]byte, error)
+	Decode(value []byte) ([]byte, error)
+}
+
+// chain is a Transformer applying several in sequence
+type chain []Transformer
+
+// Chain composes transformers: values are encoded by each in order and
+// decoded in reverse, e.g. Chain(GzipTransformer(gzip.BestSpeed), aes)
+// compresses before encrypting
+func Chain(ts ...Transformer) Transformer {
+	return chain(ts)
+}
+
+func (ts chain) Encode(value []byte) ([]byte, error) {
+	var err error
+	for _, t := range ts {
+		if value, err = t.Encode(value); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+func (ts chain) Decode(value []byte) ([]byte, error) {
+	var err error
+	for i := len(ts) - 1; i >= 0; i-- {
+		if value, err = ts[i].Decode(value); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// gzipTransformer compresses values with gzip
+type gzipTransformer struct {
+	level int
+}
+
+// GzipTransformer returns a Transformer compressing values with gzip at the
+// given level, such as gzip.BestSpeed
+func GzipTransformer(level int) Transformer {
+	return gzipTransformer{level: level}
+}
+
+func (t gzipTransformer) Encode(value []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, t.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(value); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (t gzipTransformer) Decode(value []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(value))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// transformerOf returns the transformer of a key's namespace, or the
+// cache's if the namespace has none
+func (c *Cache) transformerOf(key string) Transformer {
+	if t := c.CacheOpts.Namespaces[c.namespaceOf(key)].Transform; t != nil {
+		return t
+	}
+	return c.CacheOpts.Transform
+}
+
+// encode applies the transformer of a key to a value being stored
+func (c *Cache) encode(key string, value []byte) ([]byte, error) {
+	t := c.transformerOf(key)
+	if t == nil {
+		return value, nil
+	}
+	return t.Encode(value)
+}
+
+// decode reverses the transformer of a key on a stored value. A value that
+// fails to decode is reported as ErrCorrupted.
+func (c *Cache) decode(key string, value []byte) ([]byte, error) {
+	t := c.transformerOf(key)
+	if t == nil {
+		return value, nil
+	}
+	value, err := t.Decode(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupted, err)
+	}
+	return value, nil
+}
+
+// decoded returns the value of an item as it was written
+func (c *Cache) decoded(key string) ([]byte, error) {
+	return c.decode(key, c.valueOf(key))
+}
+
+// read returns the value of an item for a read, removing an item that
+// fails to decode
+func (c *Cache) read(key string) ([]byte, error) {
+	value, err := c.decoded(key)
+	if err != nil {
+		c.trace(key, "evict", "decode")
+		c.remove(key)
+	}
+	return value, err
+}
+
+// plain decodes a value passed to a callback, which gets nil for a value
+// that fails to decode. The failure is reported to the ErrorHandler.
+func (c *Cache) plain(key string, value []byte) []byte {
+	value, err := c.decode(key, value)
+	if err != nil {
+		c.reportError(fmt.Errorf("decoding %q: %w", c.displayKey([]byte(key)), err))
+	}
+	return value
+}
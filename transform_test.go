@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTransform(t *testing.T) {
+	aes, err := AESTransformer(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := GzipTransformer(gzip.BestSpeed)
+	value := bytes.Repeat([]byte("compressible "), 100)
+
+	tests := []struct {
+		name       string
+		transform  Transformer
+		namespaces map[string]NamespaceOpts
+		key        string
+		encoded    bool // Stored differently from value
+	}{
+		{name: "none", key: "k"},
+		{name: "gzip", transform: gz, key: "k", encoded: true},
+		{name: "aes", transform: aes, key: "k", encoded: true},
+		{name: "chain", transform: Chain(gz, aes), key: "k", encoded: true},
+		{name: "namespace", namespaces: map[string]NamespaceOpts{"user": {Transform: gz}}, key: "user:1", encoded: true},
+		{name: "other namespace", namespaces: map[string]NamespaceOpts{"user": {Transform: gz}}, key: "post:1"},
+		{name: "namespace replaces cache", transform: aes, namespaces: map[string]NamespaceOpts{"user": {Transform: gz}}, key: "user:1", encoded: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(CacheOpts{Capacity: 10, Transform: tt.transform, Namespaces: tt.namespaces, NamespaceSeparator: ":"})
+			defer c.Close(context.Background())
+			if err := c.Put([]byte(tt.key), value); err != nil {
+				t.Fatalf("Put = %v", err)
+			}
+
+			if got := !bytes.Equal(c.valueOf(tt.key), value); got != tt.encoded {
+				t.Errorf("stored encoded = %v, want %v", got, tt.encoded)
+			}
+			got, err := c.Get([]byte(tt.key))
+			if err != nil || !bytes.Equal(got, value) {
+				t.Errorf("Get = %.20q..., %v, want %.20q...", got, err, value)
+			}
+		})
+	}
+}
+
+func TestTransformUndecodableValue(t *testing.T) {
+	c := NewCache(CacheOpts{Capacity: 10, Transform: GzipTransformer(gzip.BestSpeed)})
+	defer c.Close(context.Background())
+	c.Put([]byte("k"), []byte("v"))
+	// Stop decoding with the transformer the value was stored with
+	c.CacheOpts.Transform = Chain(c.CacheOpts.Transform, GzipTransformer(gzip.BestSpeed))
+
+	if _, err := c.Get([]byte("k")); !errors.Is(err, ErrCorrupted) {
+		t.Fatalf("Get = %v, want %v", err, ErrCorrupted)
+	}
+	if c.Len() != 0 {
+		t.Errorf("Len = %d, want the undecodable item removed", c.Len())
+	}
+}
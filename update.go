@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/dhyanio/discache/util"
+)
+
+// Update replaces the value of an existing item with fn(old), keeping the
+// item's write time and TTL so its expiry deadline is unchanged; Put resets
+// it unless KeepDeadline is set. fn runs under the cache lock, so concurrent
+// updates of the same item apply one after the other; it must not call into
+// the cache and must not modify old. If fn fails, the item is left as it was
+// and the error is returned. Updating a missing or expired item fails with a
+// *util.KeyNotFoundError.
+func (c *Cache) Update(key []byte, fn func(old []byte) ([]byte, error)) (err error) {
+	var size int
+	if c.CacheOpts.Audit != nil {
+		defer func(start time.Time) { c.audit(context.Background(), AuditPut, key, size, start, "stored", err) }(time.Now())
+	}
+	if err := c.limit(context.Background(), key); err != nil {
+		return err
+	}
+
+	defer c.reclaimGroup() // Runs after unlocking
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	if c.frozen {
+		return c.frozenError()
+	}
+
+	strKey := c.storageKey(key)
+	if _, found := c.items.Get(strKey); !found || c.collides(strKey, key) || c.expired(strKey) {
+		return &util.KeyNotFoundError{Key: string(key)}
+	}
+	old, err := c.decoded(strKey)
+	if err != nil {
+		return err
+	}
+	value, err := fn(old)
+	if err != nil {
+		return err
+	}
+	size = len(value)
+
+	restore := c.restoreDeadline(strKey)
+	if err := c.put(strKey, value); err != nil {
+		return err
+	}
+	restore()
+	c.setFingerprint(strKey, key)
+	return nil
+}
+
+// keepDeadline returns a function that, under KeepDeadline, restores the
+// write time and TTL an unexpired item has now after it is overwritten
+func (c *Cache) keepDeadline(strKey string, key []byte) func() {
+	if !c.CacheOpts.KeepDeadline {
+		return func() {}
+	}
+	if _, found := c.items.Get(strKey); !found || c.collides(strKey, key) || c.expired(strKey) {
+		return func() {}
+	}
+	return c.restoreDeadline(strKey)
+}
+
+// restoreDeadline returns a function restoring the write time and TTL an
+// item has now, after it is overwritten
+func (c *Cache) restoreDeadline(strKey string) func() {
+	written, ttl := c.timestamps[strKey], c.ttls[strKey]
+	return func() {
+		c.setWritten(strKey, written)
+		if ttl > 0 {
+			c.setTTL(strKey, ttl)
+		} else {
+			delete(c.ttls, strKey)
+			c.reschedule(strKey)
+		}
+	}
+}
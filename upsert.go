@@ -0,0 +1,40 @@
+package cache
+
+import "context"
+
+// Upsert inserts an item, or if the key already holds an unexpired value,
+// stores merge(old, value) instead. The merge runs under the cache lock so
+// concurrent writers combine their values atomically; it must not call into
+// the cache.
+func (c *Cache) Upsert(key, value []byte, merge func(old, new []byte) []byte) error {
+	return c.modify(key, len(value), func(old []byte, found bool) ([]byte, error) {
+		if found {
+			return merge(old, value), nil
+		}
+		return value, nil
+	})
+}
+
+// modify stores fn(old, found) under key, where found reports whether the
+// key holds an unexpired value old. size is the expected size of the new
+// value, used to wait for room under FullBlock. The write goes through the
+// same checks as Put. If fn fails, the item is left as it was and the error
+// is returned.
+func (c *Cache) modify(key []byte, size int, fn func(old []byte, found bool) ([]byte, error)) error {
+	return c.writeWith(context.Background(), key, size, writeOpts{}, func(strKey string) (int, error) {
+		var old []byte
+		_, found := c.items.Get(strKey)
+		found = found && !c.collides(strKey, key) && !c.expired(strKey)
+		if found {
+			var err error
+			if old, err = c.decoded(strKey); err != nil {
+				return 0, err
+			}
+		}
+		value, err := fn(old, found)
+		if err != nil {
+			return 0, err
+		}
+		return len(value), c.put(strKey, value)
+	})
+}
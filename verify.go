@@ -0,0 +1,134 @@
+package cache
+
+import "maps"
+
+// Verify checks that the cache's bookkeeping agrees with its stored
+// entries, repairs what does not, and returns the number of problems found.
+// Problems are also counted in Metrics.Drift. It holds the cache lock for
+// time proportional to the number of entries.
+func (c *Cache) Verify() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return 0
+	}
+	drift := 0
+
+	// Chunks whose value is gone
+	var orphans []string
+	c.items.Iterate(func(key string, _ []byte) bool {
+		if owner, isChunk := c.chunkOwner[key]; isChunk {
+			if _, found := c.items.Get(owner); !found {
+				orphans = append(orphans, key)
+			}
+		} else if _, ok := c.timestamps[key]; !ok {
+			orphans = append(orphans, key) // Never written through the cache
+		}
+		return true
+	})
+	for _, key := range orphans {
+		c.drop(key)
+	}
+	drift += len(orphans)
+
+	// Values missing some of their chunks
+	var broken []string
+	for key, chunkKeys := range c.chunks {
+		for _, chunkKey := range chunkKeys {
+			if _, found := c.items.Get(chunkKey); !found {
+				broken = append(broken, key)
+				break
+			}
+		}
+	}
+	for _, key := range broken {
+		c.dropChunks(key)
+		c.drop(key)
+	}
+	drift += len(broken)
+
+	// Bookkeeping of entries that are gone
+	stored := func(key string) bool {
+		_, found := c.items.Get(key)
+		return found
+	}
+	drift += deleteUnstored(c.timestamps, stored)
+	drift += deleteUnstored(c.ttls, stored)
+	drift += deleteUnstored(c.chunks, stored)
+	drift += deleteUnstored(c.chunkOwner, stored)
+	drift += deleteUnstored(c.fingerprints, stored)
+	drift += deleteUnstored(c.generations, stored)
+	drift += deleteUnstored(c.checksums, stored)
+	drift += deleteUnstored(c.promotions, stored)
+	drift += deleteUnstored(c.versions, stored)
+	for _, graph := range []map[string]keySet{c.dependencies, c.dependents} {
+		drift += deleteUnstored(graph, stored)
+		for _, keys := range graph {
+			drift += deleteUnstored(keys, stored)
+		}
+		maps.DeleteFunc(graph, func(_ string, keys keySet) bool { return len(keys) == 0 })
+	}
+
+	// Eviction order
+	tracked := make(keySet, c.items.Len())
+	var untracked []string
+	c.policy.Iterate(func(key string) bool {
+		if stored(key) {
+			tracked[key] = struct{}{}
+		} else {
+			untracked = append(untracked, key)
+		}
+		return true
+	})
+	for _, key := range untracked {
+		c.policy.Remove(key)
+	}
+	drift += len(untracked)
+	c.items.Iterate(func(key string, _ []byte) bool {
+		if _, ok := tracked[key]; !ok {
+			c.policy.RecordInsert(key)
+			drift++
+		}
+		return true
+	})
+
+	// Counters
+	var entries, bytes int64
+	nsBytes := make(map[string]int64, len(c.nsBytes))
+	c.items.Iterate(func(key string, value []byte) bool {
+		if _, isChunk := c.chunkOwner[key]; !isChunk {
+			entries++
+		}
+		bytes += int64(len(value))
+		nsBytes[c.namespaceOf(key)] += int64(len(value))
+		return true
+	})
+	if c.entries.Load() != entries {
+		c.entries.Store(entries)
+		drift++
+	}
+	if delta := bytes - c.bytes.Load(); delta != 0 {
+		c.bytes.Add(delta)
+		if c.member != nil {
+			c.CacheOpts.Group.add(c.member, delta)
+		}
+		drift++
+	}
+	maps.DeleteFunc(c.nsBytes, func(_ string, n int64) bool { return n == 0 })
+	if !maps.Equal(c.nsBytes, nsBytes) {
+		c.nsBytes = nsBytes
+		drift++
+	}
+
+	c.drift += drift
+	return drift
+}
+
+// deleteUnstored deletes the keys of m that hold no stored entry and
+// returns how many it deleted
+func deleteUnstored[V any](m map[string]V, stored func(key string) bool) int {
+	n := len(m)
+	maps.DeleteFunc(m, func(key string, _ V) bool { return !stored(key) })
+	return n - len(m)
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// PutVersioned inserts an item carrying the version it was written with
+// elsewhere, such as the Version of an entry replicated from another cache.
+// Under LastWriteWins, a write whose version is not newer than the stored
+// item's fails with ErrStaleWrite. A ttl of 0 uses the cache TTL.
+func (c *Cache) PutVersioned(key, value []byte, ttl time.Duration, version uint64) error {
+	return c.write(context.Background(), key, value, writeOpts{ttl: ttl, version: version})
+}
+
+// stamp assigns the next version to a written item. Versions are hybrid
+// logical timestamps: the write time in Unix nanoseconds, bumped past every
+// version assigned or received before, so they increase monotonically even
+// if the clock steps back.
+func (c *Cache) stamp(key string, now time.Time) {
+	c.lastVersion = max(uint64(now.UnixNano()), c.lastVersion+1)
+	c.versions[key] = c.lastVersion
+}
+
+// checkVersion fails a write carrying a version that is not newer than the
+// stored item's under LastWriteWins
+func (c *Cache) checkVersion(strKey string, key []byte, version uint64) error {
+	if !c.CacheOpts.LastWriteWins || version == 0 || !c.live(strKey, key) {
+		return nil
+	}
+	if version <= c.versions[strKey] {
+		return ErrStaleWrite
+	}
+	return nil
+}
+
+// receiveVersion replaces the version assigned to a written item with the
+// one its write carried, and advances the clock past it
+func (c *Cache) receiveVersion(key string, version uint64) {
+	if version == 0 {
+		return
+	}
+	if _, found := c.items.Get(key); !found {
+		return // Dropped on the way in
+	}
+	c.versions[key] = version
+	c.lastVersion = max(c.lastVersion, version)
+}
@@ -0,0 +1,48 @@
+package cache
+
+import "time"
+
+// trimBatch is the number of items evicted per lock acquisition while trimming
+const trimBatch = 256
+
+// trimLoop periodically trims the cache down to its low watermark
+func (c *Cache) trimLoop(done <-chan struct{}) {
+	interval := c.CacheOpts.TrimInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			for c.trim() {
+			}
+		}
+	}
+}
+
+// trim evicts up to one batch of items above the low watermark and reports
+// whether more remain
+func (c *Cache) trim() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastTrim = time.Now()
+	if c.CacheOpts.LowWatermark <= 0 {
+		return false // Disabled by Reconfigure
+	}
+
+	maxItems := int(float64(c.CacheOpts.Capacity) * c.CacheOpts.LowWatermark)
+	maxBytes := int64(float64(c.CacheOpts.MaxBytes) * c.CacheOpts.LowWatermark)
+	over := func() bool {
+		return c.items.Len() > 0 && (c.items.Len() > maxItems ||
+			(c.CacheOpts.MaxBytes > 0 && c.bytes.Load() > maxBytes))
+	}
+	for i := 0; i < trimBatch && over(); i++ {
+		c.evict("watermark")
+	}
+	return over()
+}
@@ -0,0 +1,139 @@
+package cache
+
+import "time"
+
+const (
+	wheelBits   = 6
+	wheelSlots  = 1 << wheelBits // Slots per level
+	wheelLevels = 4              // Levels, covering 64^4 ticks
+)
+
+// wheelPos is where a key is scheduled in the timing wheel
+type wheelPos struct {
+	level, slot int
+	due         uint64 // Tick at which the key expires
+}
+
+// timingWheel is a hierarchical timing wheel scheduling item expiry with
+// O(1) insertion and cancellation, at the cost of tick granularity. Keys due
+// far in the future sit in coarse upper levels and cascade down as their
+// time approaches.
+type timingWheel struct {
+	tick    time.Duration
+	start   time.Time
+	current uint64 // Last tick processed
+	levels  [wheelLevels][wheelSlots]map[string]struct{}
+	where   map[string]wheelPos
+}
+
+// newTimingWheel creates a timing wheel with the given tick
+func newTimingWheel(tick time.Duration) *timingWheel {
+	return &timingWheel{tick: tick, start: time.Now(), where: make(map[string]wheelPos)}
+}
+
+// schedule (re)schedules key to expire at deadline
+func (w *timingWheel) schedule(key string, deadline time.Time) {
+	w.cancel(key)
+	// Round up so keys never fire before their deadline's tick
+	due := uint64((deadline.Sub(w.start) + w.tick - 1) / w.tick)
+	w.place(key, max(due, w.current+1))
+}
+
+// cancel removes key from the wheel
+func (w *timingWheel) cancel(key string) {
+	if pos, ok := w.where[key]; ok {
+		delete(w.levels[pos.level][pos.slot], key)
+		delete(w.where, key)
+	}
+}
+
+// place puts key in the slot of the lowest level at which its due tick
+// shares all higher bits with the current tick, so the slot is reached
+// exactly once before the key is due
+func (w *timingWheel) place(key string, due uint64) {
+	due = max(due, w.current)
+	level := 0
+	for level < wheelLevels-1 && due>>(wheelBits*(level+1)) != w.current>>(wheelBits*(level+1)) {
+		level++
+	}
+	slot := int(due>>(wheelBits*level)) & (wheelSlots - 1)
+	if due>>(wheelBits*wheelLevels) != w.current>>(wheelBits*wheelLevels) {
+		// Beyond the wheel's range: park in the top slot cascaded when the
+		// next range starts and re-place from there until it is in range
+		slot = 0
+	}
+	if w.levels[level][slot] == nil {
+		w.levels[level][slot] = make(map[string]struct{})
+	}
+	w.levels[level][slot][key] = struct{}{}
+	w.where[key] = wheelPos{level: level, slot: slot, due: due}
+}
+
+// advance moves the wheel up to now and returns the keys that became due
+func (w *timingWheel) advance(now time.Time) []string {
+	var due []string
+	target := uint64(now.Sub(w.start) / w.tick)
+	for w.current < target {
+		w.current++
+		// Cascade upper levels whose slot boundary was reached
+		for level := wheelLevels - 1; level > 0; level-- {
+			if w.current&(1<<(wheelBits*level)-1) != 0 {
+				continue
+			}
+			// Detach the slot first, as keys may be placed back in it
+			slot := int(w.current>>(wheelBits*level)) & (wheelSlots - 1)
+			keys := w.levels[level][slot]
+			w.levels[level][slot] = nil
+			for key := range keys {
+				w.place(key, w.where[key].due)
+			}
+		}
+		slot := int(w.current) & (wheelSlots - 1)
+		for key := range w.levels[0][slot] {
+			due = append(due, key)
+			delete(w.where, key)
+		}
+		clear(w.levels[0][slot])
+	}
+	return due
+}
+
+// reschedule updates an item's place in the timing wheel after a write or a
+// TTL change
+func (c *Cache) reschedule(key string) {
+	if c.wheel == nil {
+		return
+	}
+	if ttl := c.ttlOf(key); ttl > 0 {
+		c.wheel.schedule(key, c.timestamps[key].Add(ttl))
+	} else {
+		c.wheel.cancel(key)
+	}
+}
+
+// expiryLoop advances the timing wheel every tick and expires due items
+func (c *Cache) expiryLoop(done <-chan struct{}) {
+	ticker := time.NewTicker(c.CacheOpts.ExpiryTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			c.mu.Lock()
+			c.lastExpiry = now
+			for _, key := range c.wheel.advance(now) {
+				if _, found := c.items.Get(key); !found {
+					continue
+				}
+				if c.expired(key) {
+					c.expire(key)
+				} else {
+					c.reschedule(key) // Fired within the deadline's tick
+				}
+			}
+			c.mu.Unlock()
+		}
+	}
+}
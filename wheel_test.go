@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTimingWheelFiresOnDueTick(t *testing.T) {
+	const tick = time.Millisecond
+	tests := []struct {
+		key string
+		due uint64 // In ticks from the wheel's start
+	}{
+		{"first tick", 1},
+		{"end of level 0", wheelSlots - 1},
+		{"level 1 boundary", wheelSlots},
+		{"level 1", wheelSlots + 1},
+		{"level 2", wheelSlots*wheelSlots + 3},
+		{"level 3", wheelSlots*wheelSlots*wheelSlots + 5},
+		{"beyond range", 1<<(wheelBits*wheelLevels) + 7},
+		{"two ranges out", 2<<(wheelBits*wheelLevels) + 9},
+	}
+	w := newTimingWheel(tick)
+	at := func(ticks uint64) time.Time { return w.start.Add(time.Duration(ticks) * tick) }
+	for _, tt := range tests {
+		w.schedule(tt.key, at(tt.due))
+	}
+	w.schedule("cancelled", at(2))
+	w.cancel("cancelled")
+
+	for _, tt := range tests {
+		if fired := w.advance(at(tt.due - 1)); len(fired) != 0 {
+			t.Fatalf("%q fired before tick %d", fired, tt.due)
+		}
+		if fired := w.advance(at(tt.due)); !slices.Equal(fired, []string{tt.key}) {
+			t.Fatalf("tick %d fired %q, want %q", tt.due, fired, tt.key)
+		}
+	}
+	if len(w.where) != 0 {
+		t.Errorf("%d keys left in the wheel", len(w.where))
+	}
+}
+
+func TestExpiryTickRemovesItemsUnread(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		rewrite bool // Overwrite without a TTL before it elapses
+		removed bool
+	}{
+		{name: "expires", ttl: 20 * time.Millisecond, removed: true},
+		{name: "no ttl", ttl: 0},
+		{name: "rewritten without ttl", ttl: 20 * time.Millisecond, rewrite: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(CacheOpts{Capacity: 10, ExpiryTick: 5 * time.Millisecond})
+			defer c.Close(context.Background())
+			c.PutWithTTL([]byte("k"), []byte("v"), tt.ttl)
+			if tt.rewrite {
+				c.Put([]byte("k"), []byte("v2"))
+			}
+			time.Sleep(tt.ttl + 50*time.Millisecond)
+
+			if got := c.Len() == 0; got != tt.removed {
+				t.Errorf("removed without a read = %v, want %v", got, tt.removed)
+			}
+			if got := c.Metrics().Expirations; (got == 1) != tt.removed {
+				t.Errorf("Expirations = %d", got)
+			}
+		})
+	}
+}